@@ -1,27 +1,37 @@
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
 
 	"github.com/sergenyalcin/go-xsd-validator/pkg"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "json" {
+		runJSON(os.Args[2:])
+		return
+	}
+
 	xmlPath := flag.String("xml", "", "Path to XML file (required)")
 	xsdPath := flag.String("xsd", "", "Path to XSD schema file (required)")
 	outputFormat := flag.String("format", "text", "Output format (text, json)")
+	xsdVersion := flag.String("xsd-version", "1.0", "XML Schema version (1.0, 1.1)")
+	stream := flag.Bool("stream", false, "Validate while reading, without loading the whole XML file")
 	flag.Parse()
 
 	if *xmlPath == "" || *xsdPath == "" {
 		flag.Usage()
 		os.Exit(1)
 	}
-	run(xsdPath, xmlPath, outputFormat)
+	run(xsdPath, xmlPath, outputFormat, xsdVersion, stream)
 }
 
-func run(xsdPath, xmlPath, outputFormat *string) {
+func run(xsdPath, xmlPath, outputFormat, xsdVersion *string, stream *bool) {
 	// Read XSD file
 	xsdFile, err := os.Open(*xsdPath)
 	if err != nil {
@@ -37,13 +47,7 @@ func run(xsdPath, xmlPath, outputFormat *string) {
 	}(xsdFile)
 
 	// Create validator
-	validator, err := pkg.NewValidator(xsdFile)
-	if err != nil {
-		if _, err := fmt.Fprintf(os.Stderr, "Error creating validator: %v\n", err); err != nil {
-			panic(err)
-		}
-		os.Exit(1)
-	}
+	validator := newValidator(xsdFile, *xsdPath, *xsdVersion)
 
 	// Read XML file
 	xmlFile, err := os.Open(*xmlPath)
@@ -60,7 +64,11 @@ func run(xsdPath, xmlPath, outputFormat *string) {
 	}(xmlFile)
 
 	// Validate XML
-	result, err := validator.Validate(xmlFile)
+	validate := validator.Validate
+	if *stream {
+		validate = validator.ValidateStream
+	}
+	result, err := validate(xmlFile)
 	if err != nil {
 		if _, err := fmt.Fprintf(os.Stderr, "Error during validation: %v\n", err); err != nil {
 			panic(err)
@@ -68,6 +76,102 @@ func run(xsdPath, xmlPath, outputFormat *string) {
 		os.Exit(1)
 	}
 
-	// Output results
+	// Output results, locating errors in the XML file
+	result.Filename = *xmlPath
 	result.OutputResult(*outputFormat)
 }
+
+// runJSON implements the json subcommand, which validates an XML file and
+// prints it converted to JSON.
+func runJSON(args []string) {
+	flags := flag.NewFlagSet("json", flag.ExitOnError)
+	xmlPath := flags.String("xml", "", "Path to XML file (required)")
+	xsdPath := flags.String("xsd", "", "Path to XSD schema file (required)")
+	xsdVersion := flags.String("xsd-version", "1.0", "XML Schema version (1.0, 1.1)")
+	attributePrefix := flags.String("attribute-prefix", pkg.DefaultJSONConvention.AttributePrefix, "Prefix of attribute keys")
+	textKey := flags.String("text-key", pkg.DefaultJSONConvention.TextKey, "Key of character data next to attributes or child elements")
+	if err := flags.Parse(args); err != nil {
+		os.Exit(1)
+	}
+	if *xmlPath == "" || *xsdPath == "" {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	xsdFile, err := os.Open(*xsdPath)
+	if err != nil {
+		if _, err := fmt.Fprintf(os.Stderr, "Error opening XSD file: %v\n", err); err != nil {
+			panic(err)
+		}
+		os.Exit(1)
+	}
+	validator := newValidator(xsdFile, *xsdPath, *xsdVersion, pkg.WithInfoset())
+	if err := xsdFile.Close(); err != nil {
+		os.Exit(1)
+	}
+
+	xmlFile, err := os.Open(*xmlPath)
+	if err != nil {
+		if _, err := fmt.Fprintf(os.Stderr, "Error opening XML file: %v\n", err); err != nil {
+			panic(err)
+		}
+		os.Exit(1)
+	}
+	result, err := validator.Validate(xmlFile)
+	if closeErr := xmlFile.Close(); closeErr != nil {
+		panic(closeErr)
+	}
+	if err != nil {
+		if _, err := fmt.Fprintf(os.Stderr, "Error during validation: %v\n", err); err != nil {
+			panic(err)
+		}
+		os.Exit(1)
+	}
+	if !result.Valid {
+		result.Filename = *xmlPath
+		result.OutputResult("text")
+		os.Exit(1)
+	}
+
+	output, err := pkg.ToJSON(result.Document, pkg.JSONConvention{AttributePrefix: *attributePrefix, TextKey: *textKey})
+	if err != nil {
+		if _, err := fmt.Fprintf(os.Stderr, "Error converting to JSON: %v\n", err); err != nil {
+			panic(err)
+		}
+		os.Exit(1)
+	}
+	fmt.Println(string(output))
+}
+
+// newValidator creates the validator for an XSD file, exiting if the schema
+// cannot be compiled.
+func newValidator(xsdFile io.Reader, xsdPath, xsdVersion string, opts ...pkg.Option) *pkg.Validator {
+	opts = append([]pkg.Option{
+		pkg.WithXSDVersion(pkg.XSDVersion(xsdVersion)),
+		pkg.WithSchemaResolver(resolveRelativeTo(xsdPath)),
+	}, opts...)
+	validator, err := pkg.NewValidator(xsdFile, opts...)
+	if err != nil {
+		if _, err := fmt.Fprintf(os.Stderr, "Error creating validator: %v\n", err); err != nil {
+			panic(err)
+		}
+		os.Exit(1)
+	}
+	return validator
+}
+
+// resolveRelativeTo returns a pkg.SchemaResolver that loads imported schemas
+// from their schemaLocation, relative to the directory of the importing
+// schema file.
+func resolveRelativeTo(xsdPath string) pkg.SchemaResolver {
+	return func(namespace, location string) (io.Reader, error) {
+		if location == "" {
+			return nil, fmt.Errorf("no schemaLocation given for namespace %s", namespace)
+		}
+		data, err := os.ReadFile(filepath.Join(filepath.Dir(xsdPath), location))
+		if err != nil {
+			return nil, err
+		}
+		return bytes.NewReader(data), nil
+	}
+}
@@ -1,63 +1,194 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
+// commandArgsEnv holds the arguments, separated by newlines, when the test
+// binary is started by runCommand to run the command instead of the tests.
+const commandArgsEnv = "XSD_VALIDATOR_ARGS"
+
+func TestMain(m *testing.M) {
+	if args, ok := os.LookupEnv(commandArgsEnv); ok {
+		os.Args = append([]string{"xsd-validator"}, strings.Split(args, "\n")...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCommand runs the command with the given arguments in a separate process
+// and returns its standard output and exit status.
+func runCommand(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), commandArgsEnv+"="+strings.Join(args, "\n"))
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		return stdout.String(), exitErr.ExitCode()
+	case err != nil:
+		t.Fatalf("Failed to run command: %v", err)
+	}
+	return stdout.String(), 0
+}
+
 func Test(t *testing.T) {
 	tests := []struct {
-		xsdPath      string
-		xmlPath      string
-		outputFormat string
+		name   string
+		args   []string
+		output string
+		status int
 	}{
 		{
-			xsdPath:      "../testdata/xsd/book.xsd",
-			xmlPath:      "../testdata/xml/book.xml",
-			outputFormat: "text",
+			name:   "Book",
+			args:   []string{"-xsd", "../testdata/xsd/book.xsd", "-xml", "../testdata/xml/book.xml"},
+			output: "✓ XML file '../testdata/xml/book.xml' is valid\n",
+		},
+		{
+			name:   "Complex Required",
+			args:   []string{"-xsd", "../testdata/xsd/complex_required.xsd", "-xml", "../testdata/xml/complex_required.xml"},
+			output: "✓ XML file '../testdata/xml/complex_required.xml' is valid\n",
+		},
+		{
+			name:   "Deeply Nested",
+			args:   []string{"-xsd", "../testdata/xsd/deeply_nested.xsd", "-xml", "../testdata/xml/deeply_nested.xml"},
+			output: "✓ XML file '../testdata/xml/deeply_nested.xml' is valid\n",
+		},
+		{
+			name:   "Employee Directory",
+			args:   []string{"-xsd", "../testdata/xsd/employee_directory.xsd", "-xml", "../testdata/xml/employee_directory.xml"},
+			output: "✓ XML file '../testdata/xml/employee_directory.xml' is valid\n",
+		},
+		{
+			name:   "Min Max",
+			args:   []string{"-xsd", "../testdata/xsd/minmax.xsd", "-xml", "../testdata/xml/minmax.xml"},
+			output: "✓ XML file '../testdata/xml/minmax.xml' is valid\n",
+		},
+		{
+			name:   "Namespaces",
+			args:   []string{"-xsd", "../testdata/xsd/ns.xsd", "-xml", "../testdata/xml/ns.xml"},
+			output: "✓ XML file '../testdata/xml/ns.xml' is valid\n",
+		},
+		{
+			name:   "Purchase Order",
+			args:   []string{"-xsd", "../testdata/xsd/purchase_order.xsd", "-xml", "../testdata/xml/purchase_order.xml"},
+			output: "✓ XML file '../testdata/xml/purchase_order.xml' is valid\n",
 		},
 		{
-			xsdPath:      "../testdata/xsd/complex_required.xsd",
-			xmlPath:      "../testdata/xml/complex_required.xml",
-			outputFormat: "text",
+			name:   "Recursive Type",
+			args:   []string{"-xsd", "../testdata/xsd/rec_types.xsd", "-xml", "../testdata/xml/rec_structs.xml"},
+			output: "✓ XML file '../testdata/xml/rec_structs.xml' is valid\n",
 		},
 		{
-			xsdPath:      "../testdata/xsd/deeply_nested.xsd",
-			xmlPath:      "../testdata/xml/deeply_nested.xml",
-			outputFormat: "text",
+			name:   "Regex",
+			args:   []string{"-xsd", "../testdata/xsd/regex.xsd", "-xml", "../testdata/xml/regex.xml"},
+			output: "✓ XML file '../testdata/xml/regex.xml' is valid\n",
 		},
 		{
-			xsdPath:      "../testdata/xsd/employee_directory.xsd",
-			xmlPath:      "../testdata/xml/employee_directory.xml",
-			outputFormat: "text",
+			name:   "Purchase Order Streamed",
+			args:   []string{"-xsd", "../testdata/xsd/purchase_order.xsd", "-xml", "../testdata/xml/purchase_order.xml", "-stream"},
+			output: "✓ XML file '../testdata/xml/purchase_order.xml' is valid\n",
 		},
 		{
-			xsdPath:      "../testdata/xsd/minmax.xsd",
-			xmlPath:      "../testdata/xml/minmax.xml",
-			outputFormat: "text",
+			name:   "Type Not Defined",
+			args:   []string{"-xsd", "../testdata/xsd/rec_structs.xsd", "-xml", "../testdata/xml/rec_structs.xml"},
+			status: 1,
 		},
 		{
-			xsdPath:      "../testdata/xsd/ns.xsd",
-			xmlPath:      "../testdata/xml/ns.xml",
-			outputFormat: "text",
+			name: "XSD 1.1 Type With XSD 1.0",
+			args: []string{"-xsd", "../testdata/xsd/timestamp.xsd", "-xml", "../testdata/xml/timestamp.xml", "-xsd-version", "1.0"},
+			output: "✗ XML file '../testdata/xml/timestamp.xml' is invalid:\n" +
+				"  - ../testdata/xml/timestamp.xml:4:5: invalid content in element 'at': type dateTimeStamp requires XSD 1.1\n",
+		},
+		{
+			name:   "XSD 1.1 Type With XSD 1.1",
+			args:   []string{"-xsd", "../testdata/xsd/timestamp.xsd", "-xml", "../testdata/xml/timestamp.xml", "-xsd-version", "1.1"},
+			output: "✓ XML file '../testdata/xml/timestamp.xml' is valid\n",
+		},
+		{
+			name:   "Missing Flags",
+			args:   []string{"-xsd", "../testdata/xsd/book.xsd"},
+			status: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			output, status := runCommand(t, tc.args...)
+			if status != tc.status {
+				t.Errorf("Expected exit status %d, got %d", tc.status, status)
+			}
+			if output != tc.output {
+				t.Errorf("Expected output\n%s\ngot\n%s", tc.output, output)
+			}
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	expected, err := os.ReadFile("../testdata/json/purchase_order.json")
+	if err != nil {
+		t.Fatalf("Failed to read expected JSON: %v", err)
+	}
+	document, err := os.ReadFile("../testdata/xml/purchase_order.xml")
+	if err != nil {
+		t.Fatalf("Failed to read XML: %v", err)
+	}
+	invalidPath := filepath.Join(t.TempDir(), "invalid.xml")
+	invalid := strings.Replace(string(document), `sku="WX-12345"`, `sku="12345"`, 1)
+	if err := os.WriteFile(invalidPath, []byte(invalid), 0o600); err != nil {
+		t.Fatalf("Failed to write XML: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		args   []string
+		output string
+		status int
+	}{
+		{
+			name:   "Valid Document",
+			args:   []string{"json", "-xsd", "../testdata/xsd/purchase_order.xsd", "-xml", "../testdata/xml/purchase_order.xml"},
+			output: string(expected),
 		},
 		{
-			xsdPath:      "../testdata/xsd/purchase_order.xsd",
-			xmlPath:      "../testdata/xml/purchase_order.xml",
-			outputFormat: "text",
+			name: "Invalid Document",
+			args: []string{"json", "-xsd", "../testdata/xsd/purchase_order.xsd", "-xml", invalidPath},
+			output: "✗ XML file '" + invalidPath + "' is invalid:\n" +
+				"  - " + invalidPath + ":22:15: attribute 'sku': value does not match pattern: [A-Z]{2}-\\d{5}\n",
+			status: 1,
 		},
 		{
-			xsdPath:      "../testdata/xsd/rec_structs.xsd",
-			xmlPath:      "../testdata/xml/rec_structs.xml",
-			outputFormat: "text",
+			name:   "Root Element Not Declared",
+			args:   []string{"json", "-xsd", "../testdata/xsd/book.xsd", "-xml", "../testdata/xml/purchase_order.xml"},
+			status: 1,
 		},
 		{
-			xsdPath:      "../testdata/xsd/regex.xsd",
-			xmlPath:      "../testdata/xml/regex.xml",
-			outputFormat: "text",
+			name:   "Missing Flags",
+			args:   []string{"json", "-xsd", "../testdata/xsd/purchase_order.xsd"},
+			status: 1,
 		},
 	}
 
 	for _, tc := range tests {
-		run(&tc.xsdPath, &tc.xmlPath, &tc.outputFormat)
+		t.Run(tc.name, func(t *testing.T) {
+			output, status := runCommand(t, tc.args...)
+			if status != tc.status {
+				t.Errorf("Expected exit status %d, got %d", tc.status, status)
+			}
+			if output != tc.output {
+				t.Errorf("Expected output\n%s\ngot\n%s", tc.output, output)
+			}
+		})
 	}
 }
@@ -0,0 +1,195 @@
+package pkg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// xsiNamespace is the namespace of the schema instance attributes.
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+// xsiAttributes lists the schema instance attributes, which may appear on any
+// element without being declared.
+var xsiAttributes = map[string]bool{
+	"type":                      true,
+	"nil":                       true,
+	"schemaLocation":            true,
+	"noNamespaceSchemaLocation": true,
+}
+
+// xmlAttributes holds the declarations of the attributes in the xml
+// namespace, so that schemas can refer to them without importing xml.xsd.
+var xmlAttributes = map[string]XSDAttribute{
+	"lang": {Name: "lang", SimpleType: &XSDSimpleType{Union: &XSDUnion{
+		MemberTypes: "xs:language",
+		SimpleTypes: []XSDSimpleType{{Restriction: &XSDRestriction{Base: "xs:string", Enumeration: []XSDValue{{Value: ""}}}}},
+	}}},
+	"space": {Name: "space", SimpleType: &XSDSimpleType{Restriction: &XSDRestriction{
+		Base:        "xs:NCName",
+		Enumeration: []XSDValue{{Value: "default"}, {Value: "preserve"}},
+	}}},
+	"base": {Name: "base", Type: "xs:anyURI"},
+	"id":   {Name: "id", Type: "xs:ID"},
+}
+
+// attributeKey returns the key of an attribute in XMLNode.Attributes: the
+// local name for unqualified attributes, "{uri}local" otherwise.
+func attributeKey(namespace, local string) string {
+	if namespace == "" {
+		return local
+	}
+	return fmt.Sprintf("{%s}%s", namespace, local)
+}
+
+// splitAttributeKey returns the namespace and local name of an attribute key.
+func splitAttributeKey(key string) (string, string) {
+	if rest, ok := strings.CutPrefix(key, "{"); ok {
+		if namespace, local, ok := strings.Cut(rest, "}"); ok {
+			return namespace, local
+		}
+	}
+	return "", key
+}
+
+// isSchemaInstanceAttribute reports whether an attribute key names one of the
+// schema instance attributes.
+func isSchemaInstanceAttribute(key string) bool {
+	for local := range xsiAttributes {
+		if key == attributeKey(xsiNamespace, local) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveAttribute returns the declaration an attribute use refers to and the
+// key of the attribute in XMLNode.Attributes. Local declarations are
+// qualified according to their form or the attributeFormDefault of the
+// schema; references take the namespace of the global declaration, the use
+// of the reference site, and its default or fixed value if it has one.
+func (v *Validator) resolveAttribute(attr XSDAttribute) (XSDAttribute, string, error) {
+	if attr.Ref == "" {
+		namespace := ""
+		if attr.Form == "qualified" || attr.Form == "" && v.schema.AttributeFormDefault == "qualified" {
+			namespace = v.schema.TargetNS
+		}
+		return attr, attributeKey(namespace, attr.Name), nil
+	}
+
+	decl, name, err := v.referencedAttribute(attr.Ref)
+	if err != nil {
+		return XSDAttribute{}, "", err
+	}
+	resolved := *decl
+	resolved.Use = attr.Use
+	if attr.Default != "" || attr.Fixed != "" {
+		resolved.Default, resolved.Fixed = attr.Default, attr.Fixed
+	}
+	return resolved, attributeKey(name.Space, name.Local), nil
+}
+
+// referencedAttribute returns the global attribute declaration an attribute
+// reference names and its expanded name.
+func (v *Validator) referencedAttribute(ref string) (*XSDAttribute, xml.Name, error) {
+	name, ok := resolveQName(ref, v.namespaces)
+	if !ok {
+		return nil, xml.Name{}, fmt.Errorf("referenced attribute has an unbound prefix: %s", ref)
+	}
+	decl := v.findGlobalAttribute(name)
+	if decl == nil {
+		return nil, xml.Name{}, fmt.Errorf("referenced attribute not found: %s", ref)
+	}
+	return decl, name, nil
+}
+
+// attributeSchema returns the validator of the schema document that declares
+// an attribute.
+func (v *Validator) attributeSchema(attr XSDAttribute) *Validator {
+	if attr.schema != nil {
+		return attr.schema
+	}
+	return v
+}
+
+// findGlobalAttribute returns the global attribute declaration with the given
+// expanded name, or nil if there is none.
+func (v *Validator) findGlobalAttribute(name xml.Name) *XSDAttribute {
+	if name.Space == xmlNamespace {
+		if decl, ok := xmlAttributes[name.Local]; ok {
+			return &decl
+		}
+		return nil
+	}
+	for _, schema := range append([]*Validator{v}, v.imports...) {
+		if schema.schema.TargetNS != name.Space {
+			continue
+		}
+		for i, attr := range schema.schema.Attributes {
+			if attr.Name == name.Local {
+				return &schema.schema.Attributes[i]
+			}
+		}
+	}
+	return nil
+}
+
+// checkAttributeUses verifies the attribute declarations and uses of the
+// schema: value constraints must be consistent with the use, and references
+// must name a declared attribute without redeclaring it or changing its
+// fixed value.
+func (v *Validator) checkAttributeUses() error {
+	for _, attr := range v.schema.Attributes {
+		if err := checkValueConstraint(attr, attr.Name); err != nil {
+			return err
+		}
+	}
+	return v.forEachComplexType(func(ct *XSDComplexType) error {
+		for _, attr := range ct.Attributes {
+			if err := v.checkAttributeUse(attr); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (v *Validator) checkAttributeUse(attr XSDAttribute) error {
+	if attr.Ref == "" {
+		return checkValueConstraint(attr, attr.Name)
+	}
+
+	if attr.Name != "" || attr.Type != "" || attr.Form != "" || attr.SimpleType != nil {
+		return fmt.Errorf("attribute reference %s cannot also declare a name, type or form", attr.Ref)
+	}
+	if err := checkValueConstraint(attr, attr.Ref); err != nil {
+		return err
+	}
+	decl, _, err := v.referencedAttribute(attr.Ref)
+	if err != nil {
+		return err
+	}
+	if decl.Fixed == "" {
+		return nil
+	}
+	if attr.Default != "" {
+		return fmt.Errorf("attribute reference %s cannot have a default value, its declaration is fixed to %s",
+			attr.Ref, decl.Fixed)
+	}
+	if attr.Fixed != "" && v.attributeSchema(*decl).checkFixed(attr.Fixed, decl.Fixed, decl.Type, decl.SimpleType, v.namespaces) != nil {
+		return fmt.Errorf("attribute reference %s must keep the fixed value %s of its declaration", attr.Ref, decl.Fixed)
+	}
+	return nil
+}
+
+// checkValueConstraint verifies that an attribute has at most one of default
+// and fixed, and that a default value is only given to optional attributes.
+func checkValueConstraint(attr XSDAttribute, name string) error {
+	if attr.Default != "" && attr.Fixed != "" {
+		return fmt.Errorf("attribute %s cannot have both a default and a fixed value", name)
+	}
+	if attr.Default != "" && attr.Use != "" && attr.Use != "optional" {
+		return fmt.Errorf("attribute %s has a default value and must be optional", name)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package pkg
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	hexBinaryRegexp    = regexp.MustCompile(`^([0-9a-fA-F]{2})*$`)
+	base64BinaryRegexp = regexp.MustCompile(`^[A-Za-z0-9+/= ]*$`)
+)
+
+// decodeBinary returns the octets encoded by a hexBinary or base64Binary
+// value.
+func decodeBinary(primitive string, value string) ([]byte, error) {
+	switch primitive {
+	case "hexBinary":
+		if !hexBinaryRegexp.MatchString(value) {
+			return nil, fmt.Errorf("invalid hexBinary: %s", value)
+		}
+		return hex.DecodeString(value)
+	case "base64Binary":
+		// Single spaces may separate the characters of the encoding. Strict
+		// decoding rejects the padded forms whose unused bits are not zero,
+		// which the lexical space of base64Binary excludes.
+		if !base64BinaryRegexp.MatchString(value) {
+			return nil, fmt.Errorf("invalid base64Binary: %s", value)
+		}
+		octets, err := base64.StdEncoding.Strict().DecodeString(strings.ReplaceAll(value, " ", ""))
+		if err != nil {
+			return nil, fmt.Errorf("invalid base64Binary: %s", value)
+		}
+		return octets, nil
+	}
+	return nil, fmt.Errorf("%s is not a binary type", primitive)
+}
+
+func checkHexBinary(value string) error {
+	_, err := decodeBinary("hexBinary", value)
+	return err
+}
+
+func checkBase64Binary(value string) error {
+	_, err := decodeBinary("base64Binary", value)
+	return err
+}
+
+// binaryEquality returns the equalFunc of a binary type, which compares the
+// encoded octets.
+func binaryEquality(primitive string) equalFunc {
+	return func(a, b string) bool {
+		x, err := decodeBinary(primitive, a)
+		if err != nil {
+			return false
+		}
+		y, err := decodeBinary(primitive, b)
+		if err != nil {
+			return false
+		}
+		return string(x) == string(y)
+	}
+}
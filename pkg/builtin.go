@@ -0,0 +1,332 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// builtinType describes a built-in XSD datatype and its position in the
+// built-in type hierarchy.
+type builtinType struct {
+	// base is the built-in type this type is derived from by restriction.
+	// It is empty only for anySimpleType.
+	base string
+	// itemType is set for the built-in list types (NMTOKENS, IDREFS, ENTITIES).
+	itemType string
+	// version is the first XSD version defining the type.
+	version XSDVersion
+	// check validates the constraints the type adds to its base. The
+	// constraints of the base types are checked separately.
+	check func(value string) error
+	// minInclusive and maxInclusive are the range facets of the built-in
+	// types derived from integer.
+	minInclusive string
+	maxInclusive string
+}
+
+// builtinTypes is the built-in datatype hierarchy of XSD 1.0 and 1.1.
+var builtinTypes = map[string]builtinType{
+	"anySimpleType": {},
+	"anyAtomicType": {base: "anySimpleType", version: XSDVersion11},
+
+	// Primitive types
+	"string":       {base: "anySimpleType"},
+	"boolean":      {base: "anySimpleType", check: checkBoolean},
+	"decimal":      {base: "anySimpleType", check: checkDecimal},
+	"float":        {base: "anySimpleType", check: checkFloat},
+	"double":       {base: "anySimpleType", check: checkDouble},
+	"duration":     {base: "anySimpleType", check: checkDuration},
+	"dateTime":     {base: "anySimpleType"},
+	"time":         {base: "anySimpleType"},
+	"date":         {base: "anySimpleType"},
+	"gYearMonth":   {base: "anySimpleType"},
+	"gYear":        {base: "anySimpleType"},
+	"gMonthDay":    {base: "anySimpleType"},
+	"gDay":         {base: "anySimpleType"},
+	"gMonth":       {base: "anySimpleType"},
+	"hexBinary":    {base: "anySimpleType", check: checkHexBinary},
+	"base64Binary": {base: "anySimpleType", check: checkBase64Binary},
+	"anyURI":       {base: "anySimpleType", check: checkAnyURI},
+	"QName":        {base: "anySimpleType", check: checkQName},
+	"NOTATION":     {base: "anySimpleType", check: checkQName},
+
+	// Types derived from string
+	"normalizedString": {base: "string", check: checkNormalizedString},
+	"token":            {base: "normalizedString"},
+	"language":         {base: "token", check: matchCheck(`^[a-zA-Z]{1,8}(-[a-zA-Z0-9]{1,8})*$`)},
+	"NMTOKEN":          {base: "token", check: checkNMTOKEN},
+	"NMTOKENS":         {base: "anySimpleType", itemType: "NMTOKEN"},
+	"Name":             {base: "token", check: checkName},
+	"NCName":           {base: "Name", check: checkNCName},
+	"ID":               {base: "NCName"},
+	"IDREF":            {base: "NCName"},
+	"IDREFS":           {base: "anySimpleType", itemType: "IDREF"},
+	"ENTITY":           {base: "NCName"},
+	"ENTITIES":         {base: "anySimpleType", itemType: "ENTITY"},
+
+	// Types derived from decimal
+	"integer":            {base: "decimal", check: checkInteger},
+	"nonPositiveInteger": {base: "integer", maxInclusive: "0"},
+	"negativeInteger":    {base: "nonPositiveInteger", maxInclusive: "-1"},
+	"long":               {base: "integer", minInclusive: "-9223372036854775808", maxInclusive: "9223372036854775807"},
+	"int":                {base: "long", minInclusive: "-2147483648", maxInclusive: "2147483647"},
+	"short":              {base: "int", minInclusive: "-32768", maxInclusive: "32767"},
+	"byte":               {base: "short", minInclusive: "-128", maxInclusive: "127"},
+	"nonNegativeInteger": {base: "integer", minInclusive: "0"},
+	"unsignedLong":       {base: "nonNegativeInteger", minInclusive: "0", maxInclusive: "18446744073709551615"},
+	"unsignedInt":        {base: "unsignedLong", minInclusive: "0", maxInclusive: "4294967295"},
+	"unsignedShort":      {base: "unsignedInt", minInclusive: "0", maxInclusive: "65535"},
+	"unsignedByte":       {base: "unsignedShort", minInclusive: "0", maxInclusive: "255"},
+	"positiveInteger":    {base: "nonNegativeInteger", minInclusive: "1"},
+
+	// XSD 1.1 additions
+	"dateTimeStamp":     {base: "dateTime", version: XSDVersion11, check: checkDateTimeStamp},
+	"yearMonthDuration": {base: "duration", version: XSDVersion11, check: checkYearMonthDuration},
+	"dayTimeDuration":   {base: "duration", version: XSDVersion11, check: checkDayTimeDuration},
+}
+
+// builtinTypeName resolves a type reference to the local name of a built-in
+// type. Prefixed references must be bound to the XML Schema namespace in the
+// schema document; the conventional xs and xsd prefixes and unprefixed names
+// are accepted when they are not bound to another namespace. An unprefixed
+// name that is not bound to a namespace names a user-defined type of the
+// schema if there is one.
+func (v *Validator) builtinTypeName(typeName string) (string, bool) {
+	prefix, local := splitQName(typeName)
+	if _, ok := builtinTypes[local]; !ok {
+		return "", false
+	}
+	uri, bound := v.namespaces[prefix]
+	switch {
+	case bound:
+		return local, uri == xsdNamespace
+	case prefix == "":
+		return local, v.findSimpleType(local) == nil && v.findComplexType(local) == nil
+	case prefix == "xs", prefix == "xsd":
+		return local, true
+	}
+	return "", false
+}
+
+// builtinDerivesFrom reports whether the built-in type name is, or is derived
+// from, the built-in type ancestor.
+func builtinDerivesFrom(name, ancestor string) bool {
+	for ; name != ""; name = builtinTypes[name].base {
+		if name == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinPrimitive returns the primitive type a built-in type is derived from.
+func builtinPrimitive(name string) string {
+	for {
+		base := builtinTypes[name].base
+		if base == "" || base == "anySimpleType" {
+			return name
+		}
+		name = base
+	}
+}
+
+// validateBuiltinType checks a value against a built-in type, applying the
+// constraints of every type in its derivation chain starting at the primitive.
+func (v *Validator) validateBuiltinType(value string, name string, scope map[string]string) error {
+	bt := builtinTypes[name]
+	if bt.version == XSDVersion11 && v.version != XSDVersion11 {
+		return fmt.Errorf("type %s requires XSD 1.1", name)
+	}
+
+	if bt.itemType != "" {
+		items := strings.Fields(value)
+		if len(items) == 0 {
+			return violation("cvc-datatype-valid.1.2.1", name, value, "invalid %s value: %s", name, value)
+		}
+		for _, item := range items {
+			if err := v.validateBuiltinType(item, bt.itemType, scope); err != nil {
+				return violation("cvc-datatype-valid.1.2.1", name, value, "invalid %s value: %s", name, value)
+			}
+		}
+		return nil
+	}
+
+	var chain []string
+	for t := name; t != ""; t = builtinTypes[t].base {
+		chain = append(chain, t)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		t := builtinTypes[chain[i]]
+		if t.check != nil {
+			if err := t.check(value); err != nil {
+				return violation("cvc-datatype-valid.1.2.1", name, value, "invalid %s value: %s", name, value)
+			}
+		}
+		if t.minInclusive != "" {
+			if c, _ := compareDecimal(value, t.minInclusive); c < 0 {
+				return violation("cvc-datatype-valid.1.2.1", name, value, "invalid %s value: %s", name, value)
+			}
+		}
+		if t.maxInclusive != "" {
+			if c, _ := compareDecimal(value, t.maxInclusive); c > 0 {
+				return violation("cvc-datatype-valid.1.2.1", name, value, "invalid %s value: %s", name, value)
+			}
+		}
+	}
+
+	// Constraints that depend on the XSD version, the URI policy or the
+	// namespace bindings in scope.
+	switch builtinPrimitive(name) {
+	case "anyURI":
+		if err := v.uris.check(value); err != nil {
+			return violation("cvc-datatype-valid.1.2.1", name, value, "invalid %s value: %s: %v", name, value, err)
+		}
+	case "dateTime", "time", "date", "gYearMonth", "gYear", "gMonthDay", "gDay", "gMonth":
+		if _, err := parseDateTime(builtinPrimitive(name), value, v.version); err != nil {
+			return violation("cvc-datatype-valid.1.2.1", name, value, "invalid %s value: %s", name, value)
+		}
+	case "float", "double":
+		if value == "+INF" && v.version != XSDVersion11 {
+			return violation("cvc-datatype-valid.1.2.1", name, value, "invalid %s value: %s: +INF requires XSD 1.1", name, value)
+		}
+	case "QName", "NOTATION":
+		qname, ok := resolveQName(value, scope)
+		if !ok {
+			prefix, _ := splitQName(value)
+			return violation("cvc-datatype-valid.1.2.1", name, value, "invalid %s value: %s: prefix %s is not bound", name, value, prefix)
+		}
+		if builtinPrimitive(name) == "NOTATION" && !v.isNotation(qname) {
+			return violation("cvc-datatype-valid.1.2.1", name, value, "invalid %s value: %s: notation is not declared", name, value)
+		}
+	}
+	return nil
+}
+
+// splitQName splits a qualified name into its prefix and local part.
+func splitQName(qname string) (string, string) {
+	if i := strings.IndexByte(qname, ':'); i >= 0 {
+		return qname[:i], qname[i+1:]
+	}
+	return "", qname
+}
+
+func matchCheck(pattern string) func(string) error {
+	re := regexp.MustCompile(pattern)
+	return func(value string) error {
+		if !re.MatchString(value) {
+			return fmt.Errorf("value does not match %s", pattern)
+		}
+		return nil
+	}
+}
+
+func checkBoolean(value string) error {
+	if value != "true" && value != "false" && value != "1" && value != "0" {
+		return fmt.Errorf("invalid boolean")
+	}
+	return nil
+}
+
+func checkDecimal(value string) error {
+	_, err := parseDecimal(value)
+	return err
+}
+
+func checkFloat(value string) error {
+	_, err := parseFloat(value, 32)
+	return err
+}
+
+func checkDouble(value string) error {
+	_, err := parseFloat(value, 64)
+	return err
+}
+
+var integerRegexp = regexp.MustCompile(`^[+-]?\d+$`)
+
+func checkInteger(value string) error {
+	_, err := parseInteger(value)
+	return err
+}
+
+func checkNormalizedString(value string) error {
+	if strings.ContainsAny(value, "\t\n\r") {
+		return fmt.Errorf("tab, line feed and carriage return are not allowed")
+	}
+	return nil
+}
+
+func checkName(value string) error {
+	for i, r := range value {
+		if i == 0 && !isNameStartChar(r) || !isNameChar(r) {
+			return fmt.Errorf("invalid character %q", r)
+		}
+	}
+	if value == "" {
+		return fmt.Errorf("name must not be empty")
+	}
+	return nil
+}
+
+func checkNCName(value string) error {
+	if strings.Contains(value, ":") {
+		return fmt.Errorf("colon is not allowed")
+	}
+	return nil
+}
+
+func checkNMTOKEN(value string) error {
+	for _, r := range value {
+		if !isNameChar(r) {
+			return fmt.Errorf("invalid character %q", r)
+		}
+	}
+	if value == "" {
+		return fmt.Errorf("name token must not be empty")
+	}
+	return nil
+}
+
+func checkQName(value string) error {
+	prefix, local := splitQName(value)
+	if strings.Contains(value, ":") {
+		if err := checkName(prefix); err != nil {
+			return err
+		}
+		if err := checkNCName(prefix); err != nil {
+			return err
+		}
+	}
+	if err := checkName(local); err != nil {
+		return err
+	}
+	return checkNCName(local)
+}
+
+// isNameStartChar implements the NameStartChar production of XML 1.0.
+func isNameStartChar(r rune) bool {
+	switch {
+	case r == ':' || r == '_' || 'A' <= r && r <= 'Z' || 'a' <= r && r <= 'z':
+		return true
+	case 0xC0 <= r && r <= 0xD6, 0xD8 <= r && r <= 0xF6, 0xF8 <= r && r <= 0x2FF,
+		0x370 <= r && r <= 0x37D, 0x37F <= r && r <= 0x1FFF, 0x200C <= r && r <= 0x200D,
+		0x2070 <= r && r <= 0x218F, 0x2C00 <= r && r <= 0x2FEF, 0x3001 <= r && r <= 0xD7FF,
+		0xF900 <= r && r <= 0xFDCF, 0xFDF0 <= r && r <= 0xFFFD, 0x10000 <= r && r <= 0xEFFFF:
+		return true
+	}
+	return false
+}
+
+// isNameChar implements the NameChar production of XML 1.0.
+func isNameChar(r rune) bool {
+	switch {
+	case isNameStartChar(r):
+		return true
+	case r == '-' || r == '.' || '0' <= r && r <= '9' || r == 0xB7:
+		return true
+	case 0x300 <= r && r <= 0x36F, 0x203F <= r && r <= 0x2040:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,261 @@
+package pkg
+
+import "fmt"
+
+// compileSchema checks the parts of the schema that can be verified before
+// any document is validated and prepares them for validation.
+func (v *Validator) compileSchema() error {
+	v.qualifyElements()
+	if err := v.checkAttributeUses(); err != nil {
+		return err
+	}
+	if err := v.checkTypeReferences(); err != nil {
+		return err
+	}
+
+	return v.forEachSimpleType(func(st *XSDSimpleType) error {
+		if st.Restriction == nil {
+			return nil
+		}
+
+		// Resolving the facets along the derivation chain rejects undefined
+		// or circular base types and restrictions that widen their base.
+		seen := make(map[string]bool)
+		if st.Name != "" {
+			seen[st.Name] = true
+		}
+		f, err := v.simpleTypeFacets(st, seen)
+		if err != nil {
+			if st.Name != "" {
+				return fmt.Errorf("simple type %s: %v", st.Name, err)
+			}
+			return err
+		}
+		if st.Name != "" {
+			v.facets[st.Name] = f
+		}
+
+		if f.primitive == "NOTATION" {
+			for _, enum := range st.Restriction.Enumeration {
+				if name, ok := resolveQName(enum.Value, v.namespaces); !ok || !v.isNotation(name) {
+					return fmt.Errorf("enumeration value %s is not a declared notation", enum.Value)
+				}
+			}
+		}
+
+		for _, pattern := range st.Restriction.Pattern {
+			if _, err := v.patterns.Compile(pattern.Value); err != nil {
+				return fmt.Errorf("invalid pattern %q: %v", pattern.Value, err)
+			}
+		}
+		return nil
+	})
+}
+
+// checkTypeReferences reports type references of element and attribute
+// declarations, list item types and union member types that do not name a
+// built-in type or a type defined in the schema. References to imported
+// namespaces are not checked. Base types of restrictions are checked when
+// their facets are resolved.
+func (v *Validator) checkTypeReferences() error {
+	checkElements := func(elements []XSDElement) error {
+		for _, elem := range elements {
+			if elem.Type != "" && !v.isImportedType(elem.Type) && !v.isSimpleType(elem.Type) && v.findComplexType(elem.Type) == nil {
+				return fmt.Errorf("element %s: type %s is not defined", elem.Name, elem.Type)
+			}
+		}
+		return nil
+	}
+	checkAttributes := func(attrs []XSDAttribute) error {
+		for _, attr := range attrs {
+			if attr.Type != "" && !v.isImportedType(attr.Type) && !v.isSimpleType(attr.Type) {
+				return fmt.Errorf("attribute %s: type %s is not defined", attr.Name, attr.Type)
+			}
+		}
+		return nil
+	}
+
+	if err := checkElements(v.schema.Elements); err != nil {
+		return err
+	}
+	if err := checkAttributes(v.schema.Attributes); err != nil {
+		return err
+	}
+	err := v.forEachComplexType(func(ct *XSDComplexType) error {
+		if err := checkAttributes(ct.Attributes); err != nil {
+			return err
+		}
+		if ct.Sequence != nil {
+			if err := checkElements(ct.Sequence.Elements); err != nil {
+				return err
+			}
+		}
+		for choice := ct.Choice; choice != nil; choice = choice.Choice {
+			if err := checkElements(choice.Elements); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return v.forEachSimpleType(func(st *XSDSimpleType) error {
+		var refs []string
+		switch {
+		case st.List != nil && st.List.ItemType != "":
+			refs = append(refs, st.List.ItemType)
+		case st.Union != nil:
+			refs = st.Union.MemberTypeNames()
+		}
+		for _, ref := range refs {
+			if v.isImportedType(ref) || v.isSimpleType(ref) {
+				continue
+			}
+			if st.Name != "" {
+				return fmt.Errorf("simple type %s: type %s is not defined", st.Name, ref)
+			}
+			return fmt.Errorf("type %s is not defined", ref)
+		}
+		return nil
+	})
+}
+
+// isImportedType reports whether a type reference names a type in a
+// namespace imported by the schema.
+func (v *Validator) isImportedType(typeName string) bool {
+	prefix, _ := splitQName(typeName)
+	uri, bound := v.namespaces[prefix]
+	if !bound || uri == v.schema.TargetNS {
+		return false
+	}
+	for _, imp := range v.schema.Imports {
+		if imp.Namespace == uri {
+			return true
+		}
+	}
+	return false
+}
+
+// forEachSimpleType calls fn for every named and anonymous simple type
+// definition in the schema.
+func (v *Validator) forEachSimpleType(fn func(*XSDSimpleType) error) error {
+	for i := range v.schema.SimpleTypes {
+		if err := visitSimpleType(&v.schema.SimpleTypes[i], fn); err != nil {
+			return err
+		}
+	}
+	for i := range v.schema.ComplexTypes {
+		if err := visitComplexType(&v.schema.ComplexTypes[i], fn); err != nil {
+			return err
+		}
+	}
+	for i := range v.schema.Attributes {
+		if st := v.schema.Attributes[i].SimpleType; st != nil {
+			if err := visitSimpleType(st, fn); err != nil {
+				return err
+			}
+		}
+	}
+	for i := range v.schema.Elements {
+		if err := visitElement(&v.schema.Elements[i], fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachComplexType calls fn for every named and anonymous complex type
+// definition in the schema.
+func (v *Validator) forEachComplexType(fn func(*XSDComplexType) error) error {
+	var visit func(ct *XSDComplexType) error
+	visitElements := func(elements []XSDElement) error {
+		for i := range elements {
+			if ct := elements[i].ComplexType; ct != nil {
+				if err := visit(ct); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+	visit = func(ct *XSDComplexType) error {
+		if err := fn(ct); err != nil {
+			return err
+		}
+		if ct.Sequence != nil {
+			if err := visitElements(ct.Sequence.Elements); err != nil {
+				return err
+			}
+		}
+		for choice := ct.Choice; choice != nil; choice = choice.Choice {
+			if err := visitElements(choice.Elements); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for i := range v.schema.ComplexTypes {
+		if err := visit(&v.schema.ComplexTypes[i]); err != nil {
+			return err
+		}
+	}
+	return visitElements(v.schema.Elements)
+}
+
+func visitSimpleType(st *XSDSimpleType, fn func(*XSDSimpleType) error) error {
+	if err := fn(st); err != nil {
+		return err
+	}
+	if st.List != nil && st.List.SimpleType != nil {
+		if err := visitSimpleType(st.List.SimpleType, fn); err != nil {
+			return err
+		}
+	}
+	if st.Union != nil {
+		for i := range st.Union.SimpleTypes {
+			if err := visitSimpleType(&st.Union.SimpleTypes[i], fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func visitComplexType(ct *XSDComplexType, fn func(*XSDSimpleType) error) error {
+	for i := range ct.Attributes {
+		if st := ct.Attributes[i].SimpleType; st != nil {
+			if err := visitSimpleType(st, fn); err != nil {
+				return err
+			}
+		}
+	}
+	if ct.Sequence != nil {
+		for i := range ct.Sequence.Elements {
+			if err := visitElement(&ct.Sequence.Elements[i], fn); err != nil {
+				return err
+			}
+		}
+	}
+	for choice := ct.Choice; choice != nil; choice = choice.Choice {
+		for i := range choice.Elements {
+			if err := visitElement(&choice.Elements[i], fn); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func visitElement(elem *XSDElement, fn func(*XSDSimpleType) error) error {
+	if elem.SimpleType != nil {
+		if err := visitSimpleType(elem.SimpleType, fn); err != nil {
+			return err
+		}
+	}
+	if elem.ComplexType != nil {
+		return visitComplexType(elem.ComplexType, fn)
+	}
+	return nil
+}
@@ -0,0 +1,257 @@
+package pkg
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// dateTimeValue is a point on the time line used to compare values of the
+// date and time types. Values with a timezone are normalized to UTC; values
+// without one keep their local time.
+type dateTimeValue struct {
+	// seconds counts the seconds since 0000-01-01T00:00:00, including
+	// fractional seconds.
+	seconds *big.Rat
+	// timezone reports whether the value has a timezone.
+	timezone bool
+}
+
+const (
+	yearPattern     = `(?P<year>-?(?:[1-9]\d{3,}|0\d{3}))`
+	monthPattern    = `(?P<month>\d{2})`
+	dayPattern      = `(?P<day>\d{2})`
+	timePattern     = `(?P<hour>\d{2}):(?P<minute>\d{2}):(?P<second>\d{2}(?:\.\d+)?)`
+	timezonePattern = `(?P<tz>Z|[+-]\d{2}:\d{2})?`
+)
+
+// dateTimeRegexps holds the lexical spaces of the date and time primitives.
+var dateTimeRegexps = map[string]*regexp.Regexp{
+	"dateTime":   regexp.MustCompile(`^` + yearPattern + `-` + monthPattern + `-` + dayPattern + `T` + timePattern + timezonePattern + `$`),
+	"date":       regexp.MustCompile(`^` + yearPattern + `-` + monthPattern + `-` + dayPattern + timezonePattern + `$`),
+	"time":       regexp.MustCompile(`^` + timePattern + timezonePattern + `$`),
+	"gYearMonth": regexp.MustCompile(`^` + yearPattern + `-` + monthPattern + timezonePattern + `$`),
+	"gYear":      regexp.MustCompile(`^` + yearPattern + timezonePattern + `$`),
+	"gMonthDay":  regexp.MustCompile(`^--` + monthPattern + `-` + dayPattern + timezonePattern + `$`),
+	"gDay":       regexp.MustCompile(`^---` + dayPattern + timezonePattern + `$`),
+	"gMonth":     regexp.MustCompile(`^--` + monthPattern + timezonePattern + `$`),
+}
+
+// Reference values for the properties a date or time type does not have.
+// December 1972 is a leap year month with 31 days, so every gDay and
+// gMonthDay value is valid against it.
+const (
+	referenceYear  = 1972
+	referenceMonth = 12
+	referenceDay   = 31
+)
+
+// maxTimezoneOffset is the largest timezone offset, 14 hours, in seconds.
+const maxTimezoneOffset = 14 * 60 * 60
+
+// maxYear bounds the years of date and time values, so that the number of
+// seconds since year zero fits in an int64. Processors may limit the range
+// of years they support; four-digit years are always supported.
+const maxYear = 999999999
+
+// parseDateTime parses a value of one of the date and time primitives. The
+// numbering of years before the common era depends on the XSD version: XSD
+// 1.0 has no year zero and -0001 is 1 BCE, while XSD 1.1 uses astronomical
+// numbering, in which 0000 is 1 BCE and -0001 is 2 BCE.
+func parseDateTime(primitive string, value string, version XSDVersion) (dateTimeValue, error) {
+	re := dateTimeRegexps[primitive]
+	match := re.FindStringSubmatch(value)
+	if match == nil {
+		return dateTimeValue{}, fmt.Errorf("invalid %s: %s", primitive, value)
+	}
+	field := func(name string) string {
+		if i := re.SubexpIndex(name); i >= 0 {
+			return match[i]
+		}
+		return ""
+	}
+
+	year, month, day := int64(referenceYear), referenceMonth, referenceDay
+	hour, minute := 0, 0
+	second := new(big.Rat)
+
+	if s := field("year"); s != "" {
+		y, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || y > maxYear || y < -maxYear {
+			return dateTimeValue{}, fmt.Errorf("year out of range: %s", s)
+		}
+		switch {
+		case version == XSDVersion11:
+		case y == 0:
+			return dateTimeValue{}, fmt.Errorf("year 0000 requires XSD 1.1")
+		case y < 0:
+			// Convert to astronomical numbering.
+			y++
+		}
+		year = y
+	}
+	if s := field("month"); s != "" {
+		month, _ = strconv.Atoi(s)
+		if month < 1 || month > 12 {
+			return dateTimeValue{}, fmt.Errorf("month out of range: %s", s)
+		}
+	}
+	if s := field("day"); s != "" {
+		day, _ = strconv.Atoi(s)
+		limit := 31
+		switch {
+		case field("year") != "":
+			limit = daysInMonth(year, month)
+		case field("month") != "":
+			// gMonthDay allows --02-29.
+			limit = daysInMonth(2000, month)
+		}
+		if day < 1 || day > limit {
+			return dateTimeValue{}, fmt.Errorf("day out of range: %s", s)
+		}
+	}
+	if s := field("hour"); s != "" {
+		hour, _ = strconv.Atoi(s)
+		minute, _ = strconv.Atoi(field("minute"))
+		second.SetString(field("second"))
+		if minute > 59 || second.Cmp(big.NewRat(60, 1)) >= 0 {
+			return dateTimeValue{}, fmt.Errorf("invalid time of day: %s", value)
+		}
+		switch {
+		case hour == 24 && (minute != 0 || second.Sign() != 0):
+			return dateTimeValue{}, fmt.Errorf("24:00:00 is the only valid time with hour 24")
+		case hour == 24 && primitive == "time":
+			// 24:00:00 is the same time of day as 00:00:00.
+			hour = 0
+		case hour > 24:
+			return dateTimeValue{}, fmt.Errorf("hour out of range: %s", s)
+		}
+	}
+
+	offset := 0
+	tz := field("tz")
+	if tz != "" && tz != "Z" {
+		hours, _ := strconv.Atoi(tz[1:3])
+		minutes, _ := strconv.Atoi(tz[4:6])
+		if minutes > 59 || hours > 14 || hours == 14 && minutes != 0 {
+			return dateTimeValue{}, fmt.Errorf("timezone out of range: %s", tz)
+		}
+		offset = (hours*60 + minutes) * 60
+		if tz[0] == '-' {
+			offset = -offset
+		}
+	}
+
+	// A time of 24:00:00 is carried into the next day by the arithmetic.
+	seconds := new(big.Rat).SetInt64(daysFromCivil(year, month, day)*86400 + int64(hour*3600+minute*60-offset))
+	return dateTimeValue{
+		seconds:  seconds.Add(seconds, second),
+		timezone: tz != "",
+	}, nil
+}
+
+// time returns the value as a time.Time. Values with a timezone are in UTC,
+// values without one in NoTimezone. Fractional seconds are truncated to
+// nanoseconds.
+func (dt dateTimeValue) time() time.Time {
+	unix := new(big.Rat).Sub(dt.seconds, new(big.Rat).SetInt64(daysFromCivil(1970, 1, 1)*86400))
+	seconds := new(big.Int).Div(unix.Num(), unix.Denom())
+	fraction := unix.Sub(unix, new(big.Rat).SetInt(seconds))
+	nanoseconds := new(big.Int).Div(new(big.Int).Mul(fraction.Num(), big.NewInt(1e9)), fraction.Denom())
+
+	location := time.UTC
+	if !dt.timezone {
+		location = NoTimezone
+	}
+	return time.Unix(seconds.Int64(), nanoseconds.Int64()).In(location)
+}
+
+// compareDateTimeValues implements the partial order of the date and time
+// types. Values with and without a timezone are only comparable when they are
+// more than 14 hours apart; otherwise the result is indeterminate.
+func compareDateTimeValues(a, b dateTimeValue) (int, bool) {
+	if a.timezone == b.timezone {
+		return a.seconds.Cmp(b.seconds), true
+	}
+
+	// The local value lies somewhere within 14 hours of its UTC reading.
+	local, zoned, sign := b, a, 1
+	if !a.timezone {
+		local, zoned, sign = a, b, -1
+	}
+	shift := big.NewRat(maxTimezoneOffset, 1)
+	earliest := new(big.Rat).Sub(local.seconds, shift)
+	latest := new(big.Rat).Add(local.seconds, shift)
+	switch {
+	case zoned.seconds.Cmp(earliest) < 0:
+		return -sign, true
+	case zoned.seconds.Cmp(latest) > 0:
+		return sign, true
+	}
+	return 0, false
+}
+
+// dateTimeComparator returns the compareFunc of a date or time primitive.
+func dateTimeComparator(primitive string, version XSDVersion) compareFunc {
+	return func(a, b string) (int, bool) {
+		x, err := parseDateTime(primitive, a, version)
+		if err != nil {
+			return 0, false
+		}
+		y, err := parseDateTime(primitive, b, version)
+		if err != nil {
+			return 0, false
+		}
+		return compareDateTimeValues(x, y)
+	}
+}
+
+// checkDateTimeStamp checks a value of xs:dateTimeStamp, which is only
+// available in XSD 1.1.
+func checkDateTimeStamp(value string) error {
+	dt, err := parseDateTime("dateTime", value, XSDVersion11)
+	if err != nil {
+		return err
+	}
+	if !dt.timezone {
+		return fmt.Errorf("timezone is required")
+	}
+	return nil
+}
+
+func isLeapYear(year int64) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+func daysInMonth(year int64, month int) int {
+	switch month {
+	case 2:
+		if isLeapYear(year) {
+			return 29
+		}
+		return 28
+	case 4, 6, 9, 11:
+		return 30
+	}
+	return 31
+}
+
+// daysFromCivil returns a day number for a date of the proleptic Gregorian
+// calendar with astronomical year numbering. Consecutive dates have
+// consecutive day numbers.
+func daysFromCivil(year int64, month, day int) int64 {
+	if month <= 2 {
+		year--
+	}
+	era := year / 400
+	if year < 0 && year%400 != 0 {
+		era--
+	}
+	yearOfEra := year - era*400
+	monthIndex := int64((month + 9) % 12)
+	dayOfYear := (153*monthIndex+2)/5 + int64(day) - 1
+	dayOfEra := yearOfEra*365 + yearOfEra/4 - yearOfEra/100 + dayOfYear
+	return era*146097 + dayOfEra
+}
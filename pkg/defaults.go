@@ -0,0 +1,48 @@
+package pkg
+
+// valueConstraint returns the value an empty element takes: its fixed value
+// if it has one, otherwise its default value.
+func (e *XSDElement) valueConstraint() string {
+	if e.Fixed != "" {
+		return e.Fixed
+	}
+	return e.Default
+}
+
+// applyDefaults augments an element with the values its declaration supplies.
+// Attributes that are absent but declared with a default or fixed value are
+// added, and an empty element of a simple type takes the default or fixed
+// value of its declaration. Supplied values are marked as defaulted.
+func (v *Validator) applyDefaults(xmlNode *XMLNode, xsdElem *XSDElement) {
+	if xsdElem.ComplexType != nil {
+		for _, attr := range xsdElem.ComplexType.Attributes {
+			// Unresolvable references are reported by validateAttributes.
+			decl, key, err := v.resolveAttribute(attr)
+			if err != nil || decl.Use == "prohibited" {
+				continue
+			}
+			if _, ok := xmlNode.Attributes[key]; ok {
+				continue
+			}
+			value := decl.Fixed
+			if value == "" {
+				value = decl.Default
+			}
+			if value == "" {
+				continue
+			}
+			xmlNode.Attributes[key] = value
+			if xmlNode.DefaultedAttributes == nil {
+				xmlNode.DefaultedAttributes = make(map[string]bool)
+			}
+			xmlNode.DefaultedAttributes[key] = true
+		}
+		return
+	}
+
+	if value := xsdElem.valueConstraint(); value != "" && xmlNode.RawContent == "" && len(xmlNode.Children) == 0 {
+		xmlNode.Content = value
+		xmlNode.RawContent = value
+		xmlNode.Defaulted = true
+	}
+}
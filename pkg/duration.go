@@ -0,0 +1,190 @@
+package pkg
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// durationValue is the value of an xs:duration: a number of months and a
+// number of seconds, both carrying the sign of the duration.
+type durationValue struct {
+	months  int64
+	seconds *big.Rat
+}
+
+var durationRegexp = regexp.MustCompile(`^(?P<sign>-)?P` +
+	`(?:(?P<years>\d+)Y)?(?:(?P<months>\d+)M)?(?:(?P<days>\d+)D)?` +
+	`(?:T(?:(?P<hours>\d+)H)?(?:(?P<minutes>\d+)M)?(?:(?P<seconds>\d+(?:\.\d*)?|\.\d+)S)?)?$`)
+
+// durationReferences are the starting instants the specification uses to
+// order durations. Two durations are ordered only if adding them to each of
+// these dateTimes gives the same order.
+var durationReferences = [][2]int{
+	{1696, 9},
+	{1697, 2},
+	{1903, 3},
+	{1903, 7},
+}
+
+// monthsPer400Years is the number of months in a cycle of the Gregorian
+// calendar, which always has daysPer400Years days.
+const (
+	monthsPer400Years = 400 * 12
+	daysPer400Years   = 146097
+)
+
+// parseDuration parses an xs:duration value.
+func parseDuration(value string) (durationValue, error) {
+	match := durationRegexp.FindStringSubmatch(value)
+	if match == nil {
+		return durationValue{}, fmt.Errorf("invalid duration: %s", value)
+	}
+	field := func(name string) string {
+		return match[durationRegexp.SubexpIndex(name)]
+	}
+
+	// At least one field must be present, and a time designator must be
+	// followed by at least one time field.
+	timeFields := field("hours") + field("minutes") + field("seconds")
+	if field("years")+field("months")+field("days")+timeFields == "" {
+		return durationValue{}, fmt.Errorf("duration must have at least one field: %s", value)
+	}
+	if strings.Contains(value, "T") && timeFields == "" {
+		return durationValue{}, fmt.Errorf("duration time designator must be followed by a time field: %s", value)
+	}
+
+	var years, months int64
+	var err error
+	if s := field("years"); s != "" {
+		if years, err = strconv.ParseInt(s, 10, 64); err != nil || years > math.MaxInt64/12 {
+			return durationValue{}, fmt.Errorf("duration out of range: %s", value)
+		}
+	}
+	if s := field("months"); s != "" {
+		if months, err = strconv.ParseInt(s, 10, 64); err != nil || months > math.MaxInt64-years*12 {
+			return durationValue{}, fmt.Errorf("duration out of range: %s", value)
+		}
+	}
+
+	seconds := new(big.Rat)
+	units := []struct {
+		name   string
+		factor int64
+	}{
+		{"days", 86400},
+		{"hours", 3600},
+		{"minutes", 60},
+		{"seconds", 1},
+	}
+	for _, unit := range units {
+		s := field(unit.name)
+		if s == "" {
+			continue
+		}
+		n, ok := new(big.Rat).SetString(strings.TrimSuffix(s, "."))
+		if !ok {
+			return durationValue{}, fmt.Errorf("invalid duration: %s", value)
+		}
+		seconds.Add(seconds, n.Mul(n, big.NewRat(unit.factor, 1)))
+	}
+
+	d := durationValue{months: years*12 + months, seconds: seconds}
+	if field("sign") != "" {
+		d.months = -d.months
+		d.seconds.Neg(d.seconds)
+	}
+	return d, nil
+}
+
+// compareDurationValues implements the partial order of xs:duration. Values
+// are compared by adding them to each reference dateTime; if the results are
+// not ordered the same way for every reference, they are incomparable.
+func compareDurationValues(a, b durationValue) (int, bool) {
+	if a.months == b.months {
+		return a.seconds.Cmp(b.seconds), true
+	}
+	if a.seconds.Cmp(b.seconds) == 0 {
+		return compareInt64(a.months, b.months), true
+	}
+
+	result := 0
+	for i, ref := range durationReferences {
+		x := new(big.Rat).Add(monthsToSeconds(ref[0], ref[1], a.months), a.seconds)
+		y := new(big.Rat).Add(monthsToSeconds(ref[0], ref[1], b.months), b.seconds)
+		c := x.Cmp(y)
+		if i > 0 && c != result {
+			return 0, false
+		}
+		result = c
+	}
+	return result, true
+}
+
+// monthsToSeconds returns the number of seconds between the first day of the
+// given month and the first day of the month the given number of months
+// later.
+func monthsToSeconds(year, month int, months int64) *big.Rat {
+	cycles := months / monthsPer400Years
+	rest := months % monthsPer400Years
+	if rest < 0 {
+		cycles--
+		rest += monthsPer400Years
+	}
+
+	index := int64(month-1) + rest
+	days := daysFromCivil(int64(year)+index/12, int(index%12)+1, 1) - daysFromCivil(int64(year), month, 1)
+
+	total := new(big.Int).Mul(big.NewInt(cycles), big.NewInt(daysPer400Years))
+	total.Add(total, big.NewInt(days))
+	total.Mul(total, big.NewInt(86400))
+	return new(big.Rat).SetInt(total)
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareDuration compares two xs:duration values.
+func compareDuration(a, b string) (int, bool) {
+	x, err := parseDuration(a)
+	if err != nil {
+		return 0, false
+	}
+	y, err := parseDuration(b)
+	if err != nil {
+		return 0, false
+	}
+	return compareDurationValues(x, y)
+}
+
+func checkDuration(value string) error {
+	_, err := parseDuration(value)
+	return err
+}
+
+// checkYearMonthDuration accepts durations with only year and month fields.
+func checkYearMonthDuration(value string) error {
+	if strings.ContainsAny(value, "DT") {
+		return fmt.Errorf("yearMonthDuration must only have year and month fields: %s", value)
+	}
+	return nil
+}
+
+// checkDayTimeDuration accepts durations without year and month fields.
+func checkDayTimeDuration(value string) error {
+	date, _, _ := strings.Cut(value, "T")
+	if strings.ContainsAny(date, "YM") {
+		return fmt.Errorf("dayTimeDuration must not have year or month fields: %s", value)
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package pkg
+
+import "testing"
+
+func TestCompareDuration(t *testing.T) {
+	tests := []struct {
+		a, b       string
+		want       int
+		comparable bool
+	}{
+		{a: "P1Y", b: "P12M", want: 0, comparable: true},
+		{a: "PT36H", b: "P1DT12H", want: 0, comparable: true},
+		{a: "P1Y", b: "P364D", want: 1, comparable: true},
+		{a: "P1Y", b: "P365D", comparable: false},
+		{a: "P1Y", b: "P366D", comparable: false},
+		{a: "P1Y", b: "P367D", want: -1, comparable: true},
+		{a: "P1M", b: "P27D", want: 1, comparable: true},
+		{a: "P1M", b: "P28D", comparable: false},
+		{a: "P1M", b: "P31D", comparable: false},
+		{a: "P1M", b: "P32D", want: -1, comparable: true},
+		{a: "-P1M", b: "-P32D", want: 1, comparable: true},
+		{a: "P400Y", b: "P146097D", want: 0, comparable: true},
+		{a: "PT0.5S", b: "PT.5S", want: 0, comparable: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.a+" "+tc.b, func(t *testing.T) {
+			got, ok := compareDuration(tc.a, tc.b)
+			if ok != tc.comparable {
+				t.Fatalf("comparable = %v, want %v", ok, tc.comparable)
+			}
+			if ok && got != tc.want {
+				t.Errorf("compare = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package pkg
+
+import "strings"
+
+// equalFunc reports whether two lexical values denote the same value in the
+// value space of a type.
+type equalFunc func(a, b string) bool
+
+// equality returns the function comparing values of a primitive type for
+// equality. The first value is resolved against the namespace bindings in
+// scope and the second against those of the schema document. Values of types
+// without a dedicated comparison are compared lexically.
+func (v *Validator) equality(primitive string, scope map[string]string) equalFunc {
+	switch primitive {
+	case "boolean":
+		return equalBoolean
+	case "duration":
+		return equalDuration
+	case "hexBinary", "base64Binary":
+		return binaryEquality(primitive)
+	case "float":
+		return floatEquality(32)
+	case "double":
+		return floatEquality(64)
+	case "QName", "NOTATION":
+		return func(a, b string) bool {
+			return v.equalQName(a, scope, b)
+		}
+	}
+	if compare := comparator(primitive, v.version); compare != nil {
+		return func(a, b string) bool {
+			c, ok := compare(a, b)
+			return ok && c == 0
+		}
+	}
+	return func(a, b string) bool { return a == b }
+}
+
+// valuesEqual reports whether a whitespace-normalized value, appearing where
+// the namespace bindings of scope apply, equals a value given in the schema in
+// the value space of a type. List values are equal if their items are equal
+// pairwise; union values are compared in the member type both values belong
+// to.
+func (v *Validator) valuesEqual(typeName string, a, b string, scope map[string]string) bool {
+	if v.isListType(typeName) {
+		x, y := listItems(a), listItems(b)
+		if len(x) != len(y) {
+			return false
+		}
+		equal := v.equality(v.listItemPrimitive(typeName), scope)
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if union := v.unionOf(typeName); union != nil {
+		x, errX := v.matchUnionMember(a, union, scope)
+		y, errY := v.matchUnionMember(b, union, v.namespaces)
+		if errX != nil || errY != nil || x != y || strings.HasPrefix(x, "#") {
+			return a == b
+		}
+		whiteSpace := v.whiteSpace(x, nil)
+		return v.valuesEqual(x, normalizeWhiteSpace(a, whiteSpace), normalizeWhiteSpace(b, whiteSpace), scope)
+	}
+
+	return v.equality(v.primitiveType(typeName), scope)(a, b)
+}
+
+// checkFixed verifies that a value equals the fixed value constraint of an
+// element or attribute declaration in the value space of the declared type,
+// given by name or as an anonymous simple type.
+func (v *Validator) checkFixed(value, fixed string, typeName string, simpleType *XSDSimpleType, scope map[string]string) error {
+	if fixed == "" {
+		return nil
+	}
+
+	var restrictions *XSDRestriction
+	whiteSpace := ""
+	switch {
+	case simpleType == nil:
+	case simpleType.Restriction != nil:
+		typeName, restrictions = simpleType.Restriction.Base, simpleType.Restriction
+	default:
+		// Anonymous list and union types are compared item by item in
+		// their collapsed lexical form.
+		typeName, whiteSpace = "", whiteSpaceCollapse
+	}
+	if whiteSpace == "" {
+		whiteSpace = v.whiteSpace(typeName, restrictions)
+	}
+
+	value, fixed = normalizeWhiteSpace(value, whiteSpace), normalizeWhiteSpace(fixed, whiteSpace)
+	if !v.valuesEqual(typeName, value, fixed, scope) {
+		return violation("", fixed, value, "value '%s' must be equal to the fixed value '%s'", value, fixed)
+	}
+	return nil
+}
+
+// listItemPrimitive returns the primitive type of the items of a list type,
+// or an empty string if the items are not atomic.
+func (v *Validator) listItemPrimitive(typeName string) string {
+	if name, ok := v.builtinTypeName(typeName); ok {
+		return builtinPrimitive(builtinTypes[name].itemType)
+	}
+	st := v.findSimpleType(typeName)
+	switch {
+	case st == nil:
+		return ""
+	case st.Restriction != nil:
+		return v.listItemPrimitive(st.Restriction.Base)
+	case st.List != nil && st.List.SimpleType != nil:
+		f, err := v.simpleTypeFacets(st.List.SimpleType, make(map[string]bool))
+		if err != nil {
+			return ""
+		}
+		return f.primitive
+	case st.List != nil:
+		return v.primitiveType(st.List.ItemType)
+	}
+	return ""
+}
+
+// unionOf returns the union definition a type reference is derived from, or
+// nil if the type is not a union type.
+func (v *Validator) unionOf(typeName string) *XSDUnion {
+	st := v.findSimpleType(typeName)
+	switch {
+	case st == nil:
+		return nil
+	case st.Union != nil:
+		return st.Union
+	case st.Restriction != nil:
+		return v.unionOf(st.Restriction.Base)
+	}
+	return nil
+}
+
+func equalBoolean(a, b string) bool {
+	truth := func(s string) string {
+		switch s {
+		case "1":
+			return "true"
+		case "0":
+			return "false"
+		}
+		return s
+	}
+	return truth(a) == truth(b)
+}
+
+// equalDuration compares durations for identity: two durations are equal
+// only if they have the same number of months and the same number of seconds.
+func equalDuration(a, b string) bool {
+	x, err := parseDuration(a)
+	if err != nil {
+		return false
+	}
+	y, err := parseDuration(b)
+	if err != nil {
+		return false
+	}
+	return x.months == y.months && x.seconds.Cmp(y.seconds) == 0
+}
+
+// equalQName compares a QName value appearing where the namespace bindings of
+// scope apply with a QName value given in the schema by their expanded names.
+func (v *Validator) equalQName(a string, scope map[string]string, b string) bool {
+	x, okX := resolveQName(a, scope)
+	y, okY := resolveQName(b, v.namespaces)
+	if !okX || !okY {
+		return a == b
+	}
+	return x == y
+}
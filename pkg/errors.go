@@ -0,0 +1,105 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Severity is the severity of a ValidationError.
+type Severity string
+
+// SeverityError marks a violation of the schema. Every error the validator
+// reports has this severity.
+const SeverityError Severity = "error"
+
+// ValidationError describes one way in which a document violates the schema.
+type ValidationError struct {
+	// Code names the constraint that is violated, using the names of the
+	// validation rules (cvc-*) and schema representation constraints (src-*)
+	// of the XML Schema specification, such as "cvc-pattern-valid".
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// Path locates the offending element or attribute in the document, such
+	// as /po:order[1]/items[1]/item[3]/@sku.
+	Path string `json:"path,omitempty"`
+	// Line and Column give the position of the offending element or
+	// attribute. They are zero when the position is unknown.
+	Line   int `json:"line,omitempty"`
+	Column int `json:"column,omitempty"`
+	// Component names the schema component involved, such as "element item"
+	// or "attribute sku".
+	Component string `json:"component,omitempty"`
+	// Expected and Actual describe the violated constraint and the offending
+	// value, when they apply.
+	Expected string `json:"expected,omitempty"`
+	Actual   string `json:"actual,omitempty"`
+}
+
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+// constraintError is a validation failure that knows the constraint it
+// violates. The checks of values return it so that the element and attribute
+// validation can report the code of the failing facet or datatype.
+type constraintError struct {
+	code     string
+	expected string
+	actual   string
+	err      error
+}
+
+func (e *constraintError) Error() string {
+	return e.err.Error()
+}
+
+func (e *constraintError) Unwrap() error {
+	return e.err
+}
+
+// violation returns a constraintError with a formatted message.
+func violation(code, expected, actual string, format string, args ...any) error {
+	return &constraintError{code: code, expected: expected, actual: actual, err: fmt.Errorf(format, args...)}
+}
+
+// constraintCode sets the code of a constraintError that does not name the
+// constraint it violates. Other errors are returned unchanged.
+func constraintCode(err error, code string) error {
+	var ce *constraintError
+	if errors.As(err, &ce) && ce.code == "" {
+		ce.code = code
+	}
+	return err
+}
+
+// nodeError returns a ValidationError for an element or attribute of the
+// document at the given path and position.
+func nodeError(path string, pos Position, code, component string, expected, actual string, format string, args ...any) ValidationError {
+	return ValidationError{
+		Code:      code,
+		Severity:  SeverityError,
+		Message:   fmt.Sprintf(format, args...),
+		Path:      path,
+		Line:      pos.Line,
+		Column:    pos.Column,
+		Component: component,
+		Expected:  expected,
+		Actual:    actual,
+	}
+}
+
+// valueError returns a ValidationError for an invalid value. The code,
+// expected and actual values are taken from the constraint the value
+// violates; code is used if the error does not name one.
+func valueError(path string, pos Position, code, component string, message string, err error) ValidationError {
+	e := nodeError(path, pos, code, component, "", "", "%s%v", message, err)
+	var ce *constraintError
+	if errors.As(err, &ce) {
+		if ce.code != "" {
+			e.Code = ce.code
+		}
+		e.Expected, e.Actual = ce.expected, ce.actual
+	}
+	return e
+}
@@ -0,0 +1,398 @@
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Varieties of simple type definitions.
+const (
+	varietyAtomic = "atomic"
+	varietyList   = "list"
+	varietyUnion  = "union"
+)
+
+// facets holds the effective constraining facets of a simple type: the facets
+// of its own restriction combined with those inherited along its derivation
+// chain, together with the primitive type the chain starts from.
+type facets struct {
+	variety   string
+	primitive string
+
+	whiteSpace     XSDValue
+	length         XSDValue
+	minLength      XSDValue
+	maxLength      XSDValue
+	minInclusive   XSDValue
+	maxInclusive   XSDValue
+	minExclusive   XSDValue
+	maxExclusive   XSDValue
+	totalDigits    XSDValue
+	fractionDigits XSDValue
+}
+
+// Primitive types the length and range facets apply to.
+var (
+	lengthPrimitives = map[string]bool{
+		"string": true, "hexBinary": true, "base64Binary": true, "anyURI": true, "QName": true, "NOTATION": true,
+	}
+	orderedPrimitives = map[string]bool{
+		"decimal": true, "float": true, "double": true, "duration": true, "dateTime": true, "time": true,
+		"date": true, "gYearMonth": true, "gYear": true, "gMonthDay": true, "gDay": true, "gMonth": true,
+	}
+)
+
+// whiteSpaceOrder ranks whiteSpace values from the least to the most
+// restrictive.
+var whiteSpaceOrder = map[string]int{
+	whiteSpacePreserve: 0,
+	whiteSpaceReplace:  1,
+	whiteSpaceCollapse: 2,
+}
+
+// effectiveFacets returns the facets of a type reference. The facets of named
+// simple types are computed once when the schema is compiled.
+func (v *Validator) effectiveFacets(typeName string) (*facets, error) {
+	if name, ok := v.localTypeName(typeName); ok {
+		if f, ok := v.facets[name]; ok {
+			return f, nil
+		}
+	}
+	return v.typeFacets(typeName, make(map[string]bool))
+}
+
+func (v *Validator) typeFacets(typeName string, seen map[string]bool) (*facets, error) {
+	if name, ok := v.builtinTypeName(typeName); ok {
+		return builtinFacets(name), nil
+	}
+	st := v.findSimpleType(typeName)
+	if st == nil {
+		return nil, fmt.Errorf("type %s is not defined", typeName)
+	}
+	if seen[typeName] {
+		return nil, fmt.Errorf("circular derivation of type %s", typeName)
+	}
+	seen[typeName] = true
+	return v.simpleTypeFacets(st, seen)
+}
+
+func (v *Validator) simpleTypeFacets(st *XSDSimpleType, seen map[string]bool) (*facets, error) {
+	switch {
+	case st.Restriction != nil:
+		base, err := v.typeFacets(st.Restriction.Base, seen)
+		if err != nil {
+			return nil, err
+		}
+		return restrictFacets(base, st.Restriction, v.version)
+	case st.List != nil:
+		return &facets{variety: varietyList, whiteSpace: XSDValue{Value: whiteSpaceCollapse, Fixed: "true"}}, nil
+	case st.Union != nil:
+		return &facets{variety: varietyUnion}, nil
+	}
+	return nil, fmt.Errorf("simple type %s has no restriction", st.Name)
+}
+
+// builtinFacets returns the facets of a built-in type.
+func builtinFacets(name string) *facets {
+	f := &facets{
+		variety:    varietyAtomic,
+		primitive:  builtinPrimitive(name),
+		whiteSpace: XSDValue{Value: builtinWhiteSpace(name)},
+	}
+	if f.whiteSpace.Value == whiteSpaceCollapse && !builtinDerivesFrom(name, "string") {
+		f.whiteSpace.Fixed = "true"
+	}
+	if builtinTypes[name].itemType != "" {
+		f.variety = varietyList
+		f.primitive = ""
+		f.minLength = XSDValue{Value: "1"}
+	}
+	for t := name; t != ""; t = builtinTypes[t].base {
+		if f.minInclusive.Value == "" {
+			f.minInclusive.Value = builtinTypes[t].minInclusive
+		}
+		if f.maxInclusive.Value == "" {
+			f.maxInclusive.Value = builtinTypes[t].maxInclusive
+		}
+		if t == "integer" {
+			f.fractionDigits = XSDValue{Value: "0", Fixed: "true"}
+		}
+	}
+	return f
+}
+
+// allows reports whether a facet is applicable to a type with these facets.
+func (f *facets) allows(facet string) bool {
+	switch f.variety {
+	case varietyList:
+		switch facet {
+		case "length", "minLength", "maxLength", "whiteSpace":
+			return true
+		}
+		return false
+	case varietyUnion:
+		return false
+	}
+	if f.primitive == "anySimpleType" {
+		return true
+	}
+
+	switch facet {
+	case "length", "minLength", "maxLength":
+		return lengthPrimitives[f.primitive]
+	case "minInclusive", "maxInclusive", "minExclusive", "maxExclusive":
+		return orderedPrimitives[f.primitive]
+	case "totalDigits", "fractionDigits":
+		return f.primitive == "decimal"
+	}
+	return true
+}
+
+// restrictFacets derives the facets of a restriction from the facets of its
+// base type. It reports an error for facets that do not apply to the base
+// type, have invalid values, change a fixed facet or widen the value space
+// of the base type. Range facet values are compared under the given XSD
+// version.
+func restrictFacets(base *facets, r *XSDRestriction, version XSDVersion) (*facets, error) {
+	derived := *base
+	compare := comparator(base.primitive, version)
+
+	given := map[string]XSDValue{
+		"whiteSpace":     r.WhiteSpace,
+		"length":         r.Length,
+		"minLength":      r.MinLength,
+		"maxLength":      r.MaxLength,
+		"minInclusive":   r.MinInclusive,
+		"maxInclusive":   r.MaxInclusive,
+		"minExclusive":   r.MinExclusive,
+		"maxExclusive":   r.MaxExclusive,
+		"totalDigits":    r.TotalDigits,
+		"fractionDigits": r.FractionDigits,
+	}
+	inherited := map[string]*XSDValue{
+		"whiteSpace":     &derived.whiteSpace,
+		"length":         &derived.length,
+		"minLength":      &derived.minLength,
+		"maxLength":      &derived.maxLength,
+		"minInclusive":   &derived.minInclusive,
+		"maxInclusive":   &derived.maxInclusive,
+		"minExclusive":   &derived.minExclusive,
+		"maxExclusive":   &derived.maxExclusive,
+		"totalDigits":    &derived.totalDigits,
+		"fractionDigits": &derived.fractionDigits,
+	}
+	for _, name := range []string{"whiteSpace", "length", "minLength", "maxLength", "minInclusive", "maxInclusive",
+		"minExclusive", "maxExclusive", "totalDigits", "fractionDigits"} {
+		facet := given[name]
+		if facet.Value == "" {
+			continue
+		}
+		if !base.allows(name) {
+			return nil, fmt.Errorf("facet %s is not applicable to the base type %s", name, r.Base)
+		}
+		if current := inherited[name]; current.Fixed == "true" && !sameFacetValue(name, current.Value, facet.Value, compare) {
+			return nil, fmt.Errorf("facet %s is fixed to %s in the base type %s", name, current.Value, r.Base)
+		}
+	}
+
+	if err := restrictWhiteSpace(base, r); err != nil {
+		return nil, err
+	}
+	if err := restrictLengths(base, r); err != nil {
+		return nil, err
+	}
+	if err := restrictDigits(base, r); err != nil {
+		return nil, err
+	}
+	if err := restrictRange(base, r, compare); err != nil {
+		return nil, err
+	}
+
+	for name, facet := range given {
+		if facet.Value != "" {
+			*inherited[name] = facet
+		}
+	}
+	return &derived, nil
+}
+
+func restrictWhiteSpace(base *facets, r *XSDRestriction) error {
+	if r.WhiteSpace.Value == "" {
+		return nil
+	}
+	rank, ok := whiteSpaceOrder[r.WhiteSpace.Value]
+	if !ok {
+		return fmt.Errorf("invalid whiteSpace value %s", r.WhiteSpace.Value)
+	}
+	if baseRank, ok := whiteSpaceOrder[base.whiteSpace.Value]; ok && rank < baseRank {
+		return fmt.Errorf("whiteSpace %s is less restrictive than the base type's whiteSpace %s",
+			r.WhiteSpace.Value, base.whiteSpace.Value)
+	}
+	return nil
+}
+
+func restrictLengths(base *facets, r *XSDRestriction) error {
+	length, err := facetInt("length", r.Length)
+	if err != nil {
+		return err
+	}
+	minLength, err := facetInt("minLength", r.MinLength)
+	if err != nil {
+		return err
+	}
+	maxLength, err := facetInt("maxLength", r.MaxLength)
+	if err != nil {
+		return err
+	}
+	baseLength, _ := facetInt("length", base.length)
+	baseMinLength, _ := facetInt("minLength", base.minLength)
+	baseMaxLength, _ := facetInt("maxLength", base.maxLength)
+
+	switch {
+	case length >= 0 && baseLength >= 0 && length != baseLength:
+		return fmt.Errorf("length %d differs from the base type's length %d", length, baseLength)
+	case length >= 0 && baseMaxLength >= 0 && length > baseMaxLength:
+		return fmt.Errorf("length %d is greater than the base type's maxLength %d", length, baseMaxLength)
+	case length >= 0 && length < baseMinLength:
+		return fmt.Errorf("length %d is less than the base type's minLength %d", length, baseMinLength)
+	case minLength >= 0 && minLength < baseMinLength:
+		return fmt.Errorf("minLength %d is less than the base type's minLength %d", minLength, baseMinLength)
+	case maxLength >= 0 && baseMaxLength >= 0 && maxLength > baseMaxLength:
+		return fmt.Errorf("maxLength %d is greater than the base type's maxLength %d", maxLength, baseMaxLength)
+	case minLength >= 0 && maxLength >= 0 && minLength > maxLength:
+		return fmt.Errorf("minLength %d is greater than maxLength %d", minLength, maxLength)
+	}
+	return nil
+}
+
+func restrictDigits(base *facets, r *XSDRestriction) error {
+	totalDigits, err := facetInt("totalDigits", r.TotalDigits)
+	if err != nil {
+		return err
+	}
+	fractionDigits, err := facetInt("fractionDigits", r.FractionDigits)
+	if err != nil {
+		return err
+	}
+	baseTotalDigits, _ := facetInt("totalDigits", base.totalDigits)
+	baseFractionDigits, _ := facetInt("fractionDigits", base.fractionDigits)
+
+	switch {
+	case totalDigits == 0:
+		return fmt.Errorf("totalDigits must be positive")
+	case totalDigits > 0 && baseTotalDigits >= 0 && totalDigits > baseTotalDigits:
+		return fmt.Errorf("totalDigits %d is greater than the base type's totalDigits %d", totalDigits, baseTotalDigits)
+	case fractionDigits >= 0 && baseFractionDigits >= 0 && fractionDigits > baseFractionDigits:
+		return fmt.Errorf("fractionDigits %d is greater than the base type's fractionDigits %d",
+			fractionDigits, baseFractionDigits)
+	case fractionDigits >= 0 && totalDigits > 0 && fractionDigits > totalDigits:
+		return fmt.Errorf("fractionDigits %d is greater than totalDigits %d", fractionDigits, totalDigits)
+	}
+	return nil
+}
+
+// restrictRange checks the range facets of a restriction against each other
+// and against the range facets of the base type.
+func restrictRange(base *facets, r *XSDRestriction, compare compareFunc) error {
+	if compare == nil {
+		return nil
+	}
+	if r.MinInclusive.Value != "" && r.MinExclusive.Value != "" {
+		return fmt.Errorf("minInclusive and minExclusive cannot both be specified")
+	}
+	if r.MaxInclusive.Value != "" && r.MaxExclusive.Value != "" {
+		return fmt.Errorf("maxInclusive and maxExclusive cannot both be specified")
+	}
+
+	bounds := []struct {
+		name  string
+		value string
+	}{
+		{"minInclusive", r.MinInclusive.Value},
+		{"maxInclusive", r.MaxInclusive.Value},
+		{"minExclusive", r.MinExclusive.Value},
+		{"maxExclusive", r.MaxExclusive.Value},
+	}
+	for _, bound := range bounds {
+		if bound.value == "" {
+			continue
+		}
+		if _, ok := compare(bound.value, bound.value); !ok {
+			return fmt.Errorf("invalid %s value %s", bound.name, bound.value)
+		}
+	}
+
+	// Each rule rejects a derived bound that lies outside the base range: the
+	// derived facet, the base facet and the comparisons that widen the range.
+	rules := []struct {
+		facet, baseFacet string
+		derived, base    XSDValue
+		widens           func(c int) bool
+	}{
+		{"maxInclusive", "maxInclusive", r.MaxInclusive, base.maxInclusive, func(c int) bool { return c > 0 }},
+		{"maxInclusive", "maxExclusive", r.MaxInclusive, base.maxExclusive, func(c int) bool { return c >= 0 }},
+		{"maxExclusive", "maxExclusive", r.MaxExclusive, base.maxExclusive, func(c int) bool { return c > 0 }},
+		{"maxExclusive", "maxInclusive", r.MaxExclusive, base.maxInclusive, func(c int) bool { return c > 0 }},
+		{"minInclusive", "minInclusive", r.MinInclusive, base.minInclusive, func(c int) bool { return c < 0 }},
+		{"minInclusive", "minExclusive", r.MinInclusive, base.minExclusive, func(c int) bool { return c <= 0 }},
+		{"minExclusive", "minExclusive", r.MinExclusive, base.minExclusive, func(c int) bool { return c < 0 }},
+		{"minExclusive", "minInclusive", r.MinExclusive, base.minInclusive, func(c int) bool { return c < 0 }},
+	}
+	for _, rule := range rules {
+		if rule.derived.Value == "" || rule.base.Value == "" {
+			continue
+		}
+		if c, ok := compare(rule.derived.Value, rule.base.Value); ok && rule.widens(c) {
+			return fmt.Errorf("%s %s is outside the base type's %s %s",
+				rule.facet, rule.derived.Value, rule.baseFacet, rule.base.Value)
+		}
+	}
+
+	minValue, maxValue := r.MinInclusive.Value, r.MaxInclusive.Value
+	if minValue == "" {
+		minValue = r.MinExclusive.Value
+	}
+	if maxValue == "" {
+		maxValue = r.MaxExclusive.Value
+	}
+	if minValue != "" && maxValue != "" {
+		if c, ok := compare(minValue, maxValue); ok && c > 0 {
+			return fmt.Errorf("lower bound %s is greater than upper bound %s", minValue, maxValue)
+		}
+	}
+	return nil
+}
+
+// sameFacetValue reports whether two values of a facet are equal. Range facet
+// values are compared with the comparator of the primitive type, if it has
+// one, and the length and digits facets as integers.
+func sameFacetValue(name, a, b string, compare compareFunc) bool {
+	switch name {
+	case "whiteSpace":
+	case "minInclusive", "maxInclusive", "minExclusive", "maxExclusive":
+		if compare != nil {
+			c, ok := compare(a, b)
+			return ok && c == 0
+		}
+	default:
+		x, errX := strconv.Atoi(a)
+		y, errY := strconv.Atoi(b)
+		if errX == nil && errY == nil {
+			return x == y
+		}
+	}
+	return a == b
+}
+
+// facetInt parses the value of a facet that takes a non-negative integer. It
+// returns -1 if the facet is absent.
+func facetInt(name string, facet XSDValue) (int, error) {
+	if facet.Value == "" {
+		return -1, nil
+	}
+	n, err := strconv.Atoi(facet.Value)
+	if err != nil || n < 0 {
+		return -1, fmt.Errorf("invalid %s value %s", name, facet.Value)
+	}
+	return n, nil
+}
@@ -0,0 +1,59 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+)
+
+// SchemaResolver returns the schema document imported for a namespace. The
+// location is the schemaLocation hint of the xs:import and may be empty.
+type SchemaResolver func(namespace, location string) (io.Reader, error)
+
+// loadImports loads the schema documents imported by the schema using the
+// configured SchemaResolver. Imports of the xml namespace are not loaded, as
+// its attributes are built in. Every imported schema document is compiled by
+// a validator of its own, so that its declarations are resolved against its
+// own namespace bindings and types; only its global attribute declarations
+// are used by the importing schema. Each namespace is loaded once, even if it
+// is imported by several schema documents.
+func (v *Validator) loadImports(version float64) error {
+	if v.resolver == nil {
+		return nil
+	}
+	for _, imp := range v.schema.Imports {
+		if imp.Namespace == xmlNamespace {
+			continue
+		}
+		if imported, ok := v.loaded[imp.Namespace]; ok {
+			v.imports = append(v.imports, imported)
+			continue
+		}
+
+		r, err := v.resolver(imp.Namespace, imp.SchemaLocation)
+		if err != nil {
+			return fmt.Errorf("failed to load XSD import %s: %v", imp.Namespace, err)
+		}
+		imported := &Validator{
+			patterns:   v.patterns,
+			namespaces: make(map[string]string),
+			version:    v.version,
+			facets:     make(map[string]*facets),
+			uris:       v.uris,
+			resolver:   v.resolver,
+			loaded:     v.loaded,
+		}
+		schema, err := imported.parseSchema(r, version)
+		if err != nil {
+			return fmt.Errorf("failed to load XSD import %s: %v", imp.Namespace, err)
+		}
+		if schema.TargetNS != imp.Namespace {
+			return fmt.Errorf("failed to load XSD import %s: imported schema has target namespace %q",
+				imp.Namespace, schema.TargetNS)
+		}
+		if err := imported.useSchema(schema, version); err != nil {
+			return fmt.Errorf("failed to load XSD import %s: %v", imp.Namespace, err)
+		}
+		v.imports = append(v.imports, imported)
+	}
+	return nil
+}
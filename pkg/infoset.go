@@ -0,0 +1,296 @@
+package pkg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validity is the outcome of assessing an element or attribute.
+type Validity string
+
+const (
+	// ValidityValid means the item and everything it contains is valid.
+	ValidityValid Validity = "valid"
+	// ValidityInvalid means the item or something it contains is invalid.
+	ValidityInvalid Validity = "invalid"
+	// ValidityNotKnown means the item was not assessed.
+	ValidityNotKnown Validity = "notKnown"
+)
+
+// Attempted tells how much of an element was assessed.
+type Attempted string
+
+const (
+	// AttemptedFull means the element and all of its descendants were
+	// assessed.
+	AttemptedFull Attempted = "full"
+	// AttemptedPartial means some descendants were not assessed, for example
+	// because no declaration matched them.
+	AttemptedPartial Attempted = "partial"
+)
+
+// NoTimezone is the location of the time.Time values of dates and times that
+// have no timezone. Values with a timezone are normalized to UTC.
+var NoTimezone = time.FixedZone("", 0)
+
+// Duration is the typed value of xs:duration and the types derived from it:
+// a number of months and a number of seconds, which have the same sign.
+type Duration struct {
+	Months  int64
+	Seconds *big.Rat
+}
+
+// TypeDefinition identifies the type an element or attribute was validated
+// against.
+type TypeDefinition struct {
+	// Name is the expanded name of the type. It is empty for anonymous types.
+	Name xml.Name
+	// SimpleType and ComplexType hold the schema definition of user-defined
+	// and anonymous types. Both are nil for built-in types.
+	SimpleType  *XSDSimpleType
+	ComplexType *XSDComplexType
+}
+
+// ElementInfo is the outcome of validating an element.
+//
+// NormalizedValue, Value and MemberType are only set for elements of a
+// simple type whose content is valid. Value holds the typed value of the
+// content:
+//   - *big.Int for xs:integer and the types derived from it, *big.Rat for
+//     other decimals
+//   - float32 for xs:float and float64 for xs:double
+//   - bool for xs:boolean
+//   - time.Time for the date and time types; see NoTimezone
+//   - Duration for xs:duration and the types derived from it
+//   - []byte for xs:hexBinary and xs:base64Binary
+//   - xml.Name for xs:QName and xs:NOTATION
+//   - string for xs:string, xs:anyURI and the types derived from them
+//   - []any holding the typed values of the items for list types
+//
+// For union types Value is the typed value for the member type the value
+// matched, which MemberType names.
+type ElementInfo struct {
+	Validity  Validity
+	Attempted Attempted
+	// Declaration is the element declaration the element was validated
+	// against. For element references it is the global declaration with the
+	// occurrence constraints of the reference.
+	Declaration     *XSDElement
+	Type            TypeDefinition
+	NormalizedValue string
+	Value           any
+	MemberType      string
+	// Attributes holds the outcome for the declared attributes of the
+	// element, by attribute key.
+	Attributes map[string]*AttributeInfo
+}
+
+// AttributeInfo is the outcome of validating an attribute. Its fields have
+// the same meaning as those of ElementInfo.
+type AttributeInfo struct {
+	Validity        Validity
+	Type            TypeDefinition
+	NormalizedValue string
+	Value           any
+	MemberType      string
+}
+
+// elementInfo builds the outcome of validating an element against its
+// declaration. contentErr is the result of validating the content of the
+// element, and valid reports whether the element and its descendants are
+// valid.
+func (v *Validator) elementInfo(xmlNode *XMLNode, xsdElem *XSDElement, contentErr error, valid bool) *ElementInfo {
+	info := &ElementInfo{
+		Validity:    ValidityInvalid,
+		Attempted:   AttemptedFull,
+		Declaration: xsdElem,
+	}
+	if valid {
+		info.Validity = ValidityValid
+	}
+	for _, child := range xmlNode.Children {
+		if child.Info == nil || child.Info.Attempted != AttemptedFull {
+			info.Attempted = AttemptedPartial
+		}
+	}
+
+	switch {
+	case xsdElem.ComplexType != nil:
+		info.Type = TypeDefinition{ComplexType: xsdElem.ComplexType}
+		if xsdElem.Type != "" {
+			info.Type.Name = v.typeDefinitionName(xsdElem.Type)
+		}
+		info.Attributes = v.attributeInfos(xmlNode, xsdElem.ComplexType.Attributes)
+	case xsdElem.SimpleType != nil || xsdElem.Type != "" && v.isSimpleType(xsdElem.Type):
+		info.Type = v.simpleTypeDefinition(xsdElem.Type, xsdElem.SimpleType)
+		if contentErr == nil {
+			content := xmlNode.RawContent
+			if content == "" {
+				content = xsdElem.valueConstraint()
+			}
+			info.NormalizedValue, info.Value, info.MemberType = v.typedValue(content, xsdElem.Type, xsdElem.SimpleType, xmlNode.Namespaces)
+		}
+	default:
+		info.Type = TypeDefinition{Name: xml.Name{Space: xsdNamespace, Local: "anyType"}}
+	}
+	return info
+}
+
+// attributeInfos builds the outcome of validating the declared attributes
+// of an element.
+func (v *Validator) attributeInfos(xmlNode *XMLNode, schemaAttrs []XSDAttribute) map[string]*AttributeInfo {
+	infos := make(map[string]*AttributeInfo)
+	for _, attr := range schemaAttrs {
+		decl, key, err := v.resolveAttribute(attr)
+		if err != nil {
+			continue
+		}
+		value, ok := xmlNode.Attributes[key]
+		if !ok {
+			continue
+		}
+		schema := v.attributeSchema(decl)
+		info := &AttributeInfo{
+			Validity: ValidityValid,
+			Type:     schema.simpleTypeDefinition(decl.Type, decl.SimpleType),
+		}
+		if err := schema.validateAttributeValue(value, decl, xmlNode.Namespaces); err != nil {
+			info.Validity = ValidityInvalid
+		} else {
+			info.NormalizedValue, info.Value, info.MemberType = schema.typedValue(value, decl.Type, decl.SimpleType, xmlNode.Namespaces)
+		}
+		infos[key] = info
+	}
+	return infos
+}
+
+// simpleTypeDefinition returns the type definition of a type reference or an
+// anonymous simple type. Attributes without a type are xs:anySimpleType.
+func (v *Validator) simpleTypeDefinition(typeName string, simpleType *XSDSimpleType) TypeDefinition {
+	switch {
+	case simpleType != nil:
+		return TypeDefinition{SimpleType: simpleType}
+	case typeName == "":
+		return TypeDefinition{Name: xml.Name{Space: xsdNamespace, Local: "anySimpleType"}}
+	}
+	return TypeDefinition{Name: v.typeDefinitionName(typeName), SimpleType: v.findSimpleType(typeName)}
+}
+
+// typeDefinitionName returns the expanded name of a type reference.
+func (v *Validator) typeDefinitionName(typeName string) xml.Name {
+	if name, ok := v.builtinTypeName(typeName); ok {
+		return xml.Name{Space: xsdNamespace, Local: name}
+	}
+	name, _ := v.localTypeName(typeName)
+	return xml.Name{Space: v.schema.TargetNS, Local: name}
+}
+
+// typedValue returns the normalized value, the typed value and the matched
+// union member type of a value that is valid for a type reference or an
+// anonymous simple type.
+func (v *Validator) typedValue(value, typeName string, simpleType *XSDSimpleType, scope map[string]string) (string, any, string) {
+	if simpleType == nil {
+		if name, ok := v.builtinTypeName(typeName); ok {
+			value = normalizeWhiteSpace(value, builtinWhiteSpace(name))
+			return value, v.builtinValue(name, value, scope), ""
+		}
+		if simpleType = v.findSimpleType(typeName); simpleType == nil {
+			return value, nil, ""
+		}
+	}
+
+	switch {
+	case simpleType.Restriction != nil:
+		value = normalizeWhiteSpace(value, v.whiteSpace(simpleType.Restriction.Base, simpleType.Restriction))
+		return v.typedValue(value, simpleType.Restriction.Base, nil, scope)
+	case simpleType.List != nil:
+		value = normalizeWhiteSpace(value, whiteSpaceCollapse)
+		return value, v.listValue(value, simpleType.List.ItemType, simpleType.List.SimpleType, scope), ""
+	case simpleType.Union != nil:
+		member, err := v.matchUnionMember(value, simpleType.Union, scope)
+		if err != nil {
+			return value, nil, ""
+		}
+		if index, ok := strings.CutPrefix(member, "#"); ok {
+			i, _ := strconv.Atoi(index)
+			anonymous := &simpleType.Union.SimpleTypes[i-1-len(simpleType.Union.MemberTypeNames())]
+			normalized, typed, _ := v.typedValue(value, "", anonymous, scope)
+			return normalized, typed, member
+		}
+		normalized, typed, _ := v.typedValue(value, member, nil, scope)
+		return normalized, typed, member
+	}
+	return value, nil, ""
+}
+
+// listValue returns the typed values of the items of a list value.
+func (v *Validator) listValue(value, itemType string, simpleType *XSDSimpleType, scope map[string]string) []any {
+	items := listItems(value)
+	values := make([]any, len(items))
+	for i, item := range items {
+		_, values[i], _ = v.typedValue(item, itemType, simpleType, scope)
+	}
+	return values
+}
+
+// builtinValue returns the typed value of a normalized value of a built-in
+// type, or nil if the value is not valid for it.
+func (v *Validator) builtinValue(name, value string, scope map[string]string) any {
+	if itemType := builtinTypes[name].itemType; itemType != "" {
+		items := listItems(value)
+		values := make([]any, len(items))
+		for i, item := range items {
+			values[i] = v.builtinValue(itemType, item, scope)
+		}
+		return values
+	}
+	typed, err := v.atomicValue(name, value, scope)
+	if err != nil {
+		return nil
+	}
+	return typed
+}
+
+// atomicValue returns the typed value of a normalized value of a built-in
+// atomic type.
+func (v *Validator) atomicValue(name, value string, scope map[string]string) (any, error) {
+	switch primitive := builtinPrimitive(name); primitive {
+	case "decimal":
+		if builtinDerivesFrom(name, "integer") {
+			return parseInteger(value)
+		}
+		return parseDecimal(value)
+	case "float":
+		f, err := parseFloat(value, 32)
+		return float32(f), err
+	case "double":
+		return parseFloat(value, 64)
+	case "boolean":
+		return value == "true" || value == "1", nil
+	case "duration":
+		d, err := parseDuration(value)
+		if err != nil {
+			return nil, err
+		}
+		return Duration{Months: d.months, Seconds: d.seconds}, nil
+	case "dateTime", "time", "date", "gYearMonth", "gYear", "gMonthDay", "gDay", "gMonth":
+		dt, err := parseDateTime(primitive, value, v.version)
+		if err != nil {
+			return nil, err
+		}
+		return dt.time(), nil
+	case "hexBinary", "base64Binary":
+		return decodeBinary(primitive, value)
+	case "QName", "NOTATION":
+		qname, ok := resolveQName(value, scope)
+		if !ok {
+			return nil, fmt.Errorf("prefix of %s is not bound", value)
+		}
+		return qname, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,272 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+)
+
+// JSONConvention controls how attributes and character data are named in
+// the JSON produced by ToJSON.
+type JSONConvention struct {
+	// AttributePrefix is prepended to the local name of attributes.
+	AttributePrefix string
+	// TextKey is the key of the character data of elements that also have
+	// attributes or child elements.
+	TextKey string
+}
+
+// DefaultJSONConvention names attributes "@name" and character data "#text".
+var DefaultJSONConvention = JSONConvention{AttributePrefix: "@", TextKey: "#text"}
+
+// ToJSON converts a document annotated by a validator created WithInfoset to
+// JSON. The shape of the output follows the schema rather than the instance:
+//   - numeric values become JSON numbers and booleans become JSON booleans;
+//     the special float values NaN, INF and -INF become strings
+//   - list values become arrays
+//   - elements declared with maxOccurs greater than one always become
+//     arrays, even when they occur once
+//   - elements of a simple type without attributes become scalars, and
+//     elements of a complex type always become objects
+//
+// Other values keep their normalized lexical form as JSON strings. Keys
+// appear in document order, attributes first; schema instance attributes
+// are left out. Keys are local names, so ToJSON returns an error if two
+// attributes or child elements of an element, or its character data, would
+// have the same key, such as elements with the same local name in different
+// namespaces.
+func ToJSON(document *XMLNode, convention JSONConvention) ([]byte, error) {
+	if document == nil || document.Info == nil {
+		return nil, fmt.Errorf("document has no validation outcome, validate it with a validator created WithInfoset")
+	}
+	value, err := elementJSON(document, convention)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(jsonObject{{document.Name, value}}, "", "  ")
+}
+
+// jsonField is a member of a jsonObject.
+type jsonField struct {
+	key   string
+	value any
+}
+
+// jsonObject is a JSON object that keeps the order of its members.
+type jsonObject []jsonField
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// elementJSON returns the JSON value of an element.
+func elementJSON(node *XMLNode, convention JSONConvention) (any, error) {
+	info := node.Info
+	var object jsonObject
+	used := make(map[string]bool)
+	add := func(key string, value any) error {
+		if used[key] {
+			return fmt.Errorf("element %s: more than one attribute, child element or text has the JSON key %q", node.Path, key)
+		}
+		used[key] = true
+		object = append(object, jsonField{key, value})
+		return nil
+	}
+
+	keys := make([]string, 0, len(node.Attributes))
+	for key := range node.Attributes {
+		if !isSchemaInstanceAttribute(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		_, local := splitAttributeKey(key)
+		var value any = node.Attributes[key]
+		if info != nil {
+			if attr := info.Attributes[key]; attr != nil && attr.Value != nil {
+				value = typedJSON(attr.Value, attr.NormalizedValue)
+			}
+		}
+		if err := add(convention.AttributePrefix+local, value); err != nil {
+			return nil, err
+		}
+	}
+
+	// Child elements are grouped by expanded name in the order they first
+	// occur.
+	var names []xml.Name
+	groups := make(map[xml.Name][]*XMLNode)
+	for _, child := range node.Children {
+		name := xml.Name{Space: child.Namespace, Local: child.Name}
+		if _, ok := groups[name]; !ok {
+			names = append(names, name)
+		}
+		groups[name] = append(groups[name], child)
+	}
+
+	// The content of an element of a simple type is its typed value.
+	var text any
+	switch {
+	case info != nil && info.Value != nil:
+		text = typedJSON(info.Value, info.NormalizedValue)
+	case node.Content != "":
+		text = node.Content
+	}
+	complexType := info == nil || info.Type.ComplexType != nil
+	if len(object) == 0 && len(names) == 0 && !complexType {
+		if text == nil {
+			return "", nil
+		}
+		return text, nil
+	}
+	if text != nil {
+		if err := add(convention.TextKey, text); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range names {
+		group := groups[name]
+		if len(group) == 1 && !repeatable(node, group[0]) {
+			value, err := elementJSON(group[0], convention)
+			if err != nil {
+				return nil, err
+			}
+			if err := add(name.Local, value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		values := make([]any, len(group))
+		for i, child := range group {
+			value, err := elementJSON(child, convention)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		if err := add(name.Local, values); err != nil {
+			return nil, err
+		}
+	}
+	if object == nil {
+		return jsonObject{}, nil
+	}
+	return object, nil
+}
+
+// repeatable reports whether the content model of an element allows a child
+// element to occur more than once. The maxOccurs of the choice groups that
+// enclose the declaration of the child count as well as its own: an element
+// of a repeatable choice may repeat even if it can only occur once in each
+// choice.
+func repeatable(parent, child *XMLNode) bool {
+	if child.Info != nil && child.Info.Declaration != nil && occursMoreThanOnce(child.Info.Declaration.MaxOccurs) {
+		return true
+	}
+	if parent.Info == nil || parent.Info.Type.ComplexType == nil {
+		return false
+	}
+
+	ct := parent.Info.Type.ComplexType
+	if ct.Sequence != nil && declaresRepeatable(ct.Sequence.Elements, child.Name, false) {
+		return true
+	}
+	// Nested choice groups repeat with the groups that enclose them.
+	groupRepeats := false
+	for choice := ct.Choice; choice != nil; choice = choice.Choice {
+		if _, maxOccurs := occurrences(choice.MinOccurs, choice.MaxOccurs); maxOccurs == 0 {
+			return false
+		}
+		groupRepeats = groupRepeats || occursMoreThanOnce(choice.MaxOccurs)
+		if declaresRepeatable(choice.Elements, child.Name, groupRepeats) {
+			return true
+		}
+	}
+	return false
+}
+
+// declaresRepeatable reports whether a particle of a model group declares an
+// element with the given local name that may occur more than once, given
+// whether the group itself repeats.
+func declaresRepeatable(elements []XSDElement, name string, groupRepeats bool) bool {
+	for _, elem := range elements {
+		local := elem.Name
+		if elem.Ref != "" {
+			_, local = splitQName(elem.Ref)
+		}
+		if local != name {
+			continue
+		}
+		_, maxOccurs := occurrences(elem.MinOccurs, elem.MaxOccurs)
+		if maxOccurs > 1 || groupRepeats && maxOccurs > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// occursMoreThanOnce reports whether a maxOccurs value allows more than one
+// occurrence.
+func occursMoreThanOnce(maxOccurs string) bool {
+	_, n := occurrences("", maxOccurs)
+	return n > 1
+}
+
+// typedJSON returns the JSON value of a typed value with the given normalized
+// lexical form.
+func typedJSON(value any, lexical string) any {
+	switch value := value.(type) {
+	case bool:
+		return value
+	case *big.Int:
+		return json.Number(value.String())
+	case *big.Rat:
+		if _, fraction, err := decimalDigits(lexical); err == nil {
+			return json.Number(value.FloatString(fraction))
+		}
+	case float32:
+		if !math.IsInf(float64(value), 0) && !math.IsNaN(float64(value)) {
+			return json.Number(strconv.FormatFloat(float64(value), 'g', -1, 32))
+		}
+	case float64:
+		if !math.IsInf(value, 0) && !math.IsNaN(value) {
+			return json.Number(strconv.FormatFloat(value, 'g', -1, 64))
+		}
+	case []any:
+		items := listItems(lexical)
+		if len(items) == len(value) {
+			values := make([]any, len(value))
+			for i := range value {
+				values[i] = typedJSON(value[i], items[i])
+			}
+			return values
+		}
+	}
+	return lexical
+}
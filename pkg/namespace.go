@@ -1,70 +1,142 @@
 package pkg
 
 import (
+	"encoding/xml"
 	"fmt"
-	"strings"
+	"sort"
 )
 
+// xmlNamespace is the namespace bound to the xml prefix in every document.
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
 // findSchemaElementNS locates an XSD element definition by its name and namespace.
 func (v *Validator) findSchemaElementNS(name, namespace string, elements []XSDElement) *XSDElement {
 	for i, elem := range elements {
-		elemNS := elem.Namespace
-		if elemNS == "" {
-			elemNS = v.schema.TargetNS
-		}
-
-		if elem.Name == name {
-			// Match if:
-			// 1. Namespaces are exactly equal, or
-			// 2. Element is unqualified and we're matching against target namespace
-			if elemNS == namespace ||
-				(v.schema.ElementFormDefault != "qualified" && namespace == v.schema.TargetNS) {
-				return &elements[i]
-			}
+		if elem.Name == name && elem.targetNamespace == namespace {
+			return &elements[i]
 		}
 	}
 	return nil
 }
 
-// validateElementNameAndNS ensures the XML element matches the expected name and namespace.
+// validateElementNameAndNS ensures the XML element matches the expanded name
+// of the element declaration.
 func (v *Validator) validateElementNameAndNS(xmlNode *XMLNode, xsdElem XSDElement) bool {
-	if xmlNode.Name != xsdElem.Name {
-		return false
+	return xmlNode.Name == xsdElem.Name && xmlNode.Namespace == xsdElem.targetNamespace
+}
+
+// particleName returns the expanded name of the elements an element particle
+// of a content model matches. A reference matches the name of the global
+// element it refers to.
+func (v *Validator) particleName(particle XSDElement) xml.Name {
+	if particle.Ref != "" {
+		name, _ := resolveQName(particle.Ref, v.namespaces)
+		return name
 	}
+	return xml.Name{Space: particle.targetNamespace, Local: particle.Name}
+}
 
-	// Determine the expected namespace.
-	schemaNamespace := xsdElem.Namespace
-	if schemaNamespace == "" {
-		schemaNamespace = v.schema.TargetNS
+// resolveElementRef returns the global element declaration a reference
+// names, resolving the prefix of the reference against the namespace
+// bindings of the schema document.
+func (v *Validator) resolveElementRef(ref string) (*XSDElement, error) {
+	name, ok := resolveQName(ref, v.namespaces)
+	if ok {
+		if elem := v.findSchemaElementNS(name.Local, name.Space, v.schema.Elements); elem != nil {
+			return elem, nil
+		}
 	}
+	return nil, fmt.Errorf("referenced element not found: %s", ref)
+}
+
+// qualifyElements determines the namespace of every element declaration.
+// Global elements are in the target namespace; local elements only if they
+// are qualified by their form or by the elementFormDefault of the schema.
+func (v *Validator) qualifyElements() {
+	for i := range v.schema.Elements {
+		elem := &v.schema.Elements[i]
+		elem.targetNamespace = v.schema.TargetNS
+		if elem.Namespace != "" {
+			elem.targetNamespace = elem.Namespace
+		}
+		v.qualifyComplexType(elem.ComplexType)
+	}
+	for i := range v.schema.ComplexTypes {
+		v.qualifyComplexType(&v.schema.ComplexTypes[i])
+	}
+}
 
-	// Handle namespace validation based on schema settings.
-	if v.schema.ElementFormDefault == "qualified" {
-		if schemaNamespace == "" {
-			return xmlNode.Namespace == ""
+func (v *Validator) qualifyComplexType(ct *XSDComplexType) {
+	if ct == nil {
+		return
+	}
+	if ct.Sequence != nil {
+		for i := range ct.Sequence.Elements {
+			v.qualifyLocalElement(&ct.Sequence.Elements[i])
+		}
+	}
+	for choice := ct.Choice; choice != nil; choice = choice.Choice {
+		for i := range choice.Elements {
+			v.qualifyLocalElement(&choice.Elements[i])
 		}
-		return xmlNode.Namespace == schemaNamespace
 	}
+}
 
-	// For unqualified elements
-	return true
+func (v *Validator) qualifyLocalElement(elem *XSDElement) {
+	switch {
+	case elem.Namespace != "":
+		elem.targetNamespace = elem.Namespace
+	case elem.Form == "qualified", elem.Form == "" && v.schema.ElementFormDefault == "qualified":
+		elem.targetNamespace = v.schema.TargetNS
+	}
+	v.qualifyComplexType(elem.ComplexType)
 }
 
-func (v *Validator) resolveElementRef(ref string) (*XSDElement, error) {
-	// Handle namespace prefix in ref
-	parts := strings.Split(ref, ":")
-	var localName string
-	if len(parts) > 1 {
-		localName = parts[1]
-	} else {
-		localName = parts[0]
+// resolveQName returns the expanded name of a QName value, resolving its
+// prefix against a set of namespace bindings. An unprefixed name is in the
+// default namespace. It returns false if the prefix is not bound.
+func resolveQName(qname string, bindings map[string]string) (xml.Name, bool) {
+	prefix, local := splitQName(qname)
+	switch prefix {
+	case "":
+		return xml.Name{Space: bindings[""], Local: local}, true
+	case "xml":
+		return xml.Name{Space: xmlNamespace, Local: local}, true
 	}
+	uri, ok := bindings[prefix]
+	if !ok {
+		return xml.Name{}, false
+	}
+	return xml.Name{Space: uri, Local: local}, true
+}
 
-	// Search in schema elements
-	for _, elem := range v.schema.Elements {
-		if elem.Name == localName {
-			return &elem, nil
+// boundPrefix returns a non-empty prefix bound to a namespace, choosing the
+// first in lexical order when there are several. The xml prefix is always
+// bound to the XML namespace.
+func boundPrefix(namespace string, bindings map[string]string) (string, bool) {
+	if namespace == xmlNamespace {
+		return "xml", true
+	}
+	prefixes := make([]string, 0, 1)
+	for prefix, uri := range bindings {
+		if prefix != "" && uri == namespace {
+			prefixes = append(prefixes, prefix)
 		}
 	}
-	return nil, fmt.Errorf("referenced element not found: %s", ref)
+	if len(prefixes) == 0 {
+		return "", false
+	}
+	sort.Strings(prefixes)
+	return prefixes[0], true
+}
+
+// isNotation reports whether an expanded name names a notation declared in the
+// schema.
+func (v *Validator) isNotation(name xml.Name) bool {
+	for _, notation := range v.schema.Notations {
+		if notation.Name == name.Local && v.schema.TargetNS == name.Space {
+			return true
+		}
+	}
+	return false
 }
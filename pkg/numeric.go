@@ -0,0 +1,135 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var decimalRegexp = regexp.MustCompile(`^[+-]?(\d+(\.\d*)?|\.\d+)$`)
+
+// parseDecimal parses the lexical representation of xs:decimal into an exact
+// rational value. Exponents, which big.Rat would otherwise accept, are not
+// part of the decimal lexical space.
+func parseDecimal(value string) (*big.Rat, error) {
+	if !decimalRegexp.MatchString(value) {
+		return nil, fmt.Errorf("invalid decimal: %s", value)
+	}
+	r, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal: %s", value)
+	}
+	return r, nil
+}
+
+// parseInteger parses the lexical representation of xs:integer.
+func parseInteger(value string) (*big.Int, error) {
+	if !integerRegexp.MatchString(value) {
+		return nil, fmt.Errorf("invalid integer: %s", value)
+	}
+	n, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid integer: %s", value)
+	}
+	return n, nil
+}
+
+// compareDecimal compares two decimal values exactly.
+func compareDecimal(a, b string) (int, bool) {
+	x, err := parseDecimal(a)
+	if err != nil {
+		return 0, false
+	}
+	y, err := parseDecimal(b)
+	if err != nil {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
+
+// floatRegexp matches the lexical space of xs:float and xs:double. The special
+// values are case-sensitive; "+INF" is only allowed by XSD 1.1.
+var floatRegexp = regexp.MustCompile(`^([+-]?(\d+(\.\d*)?|\.\d+)([eE][+-]?\d+)?|[+-]?INF|NaN)$`)
+
+// parseFloat parses the lexical representation of xs:float (bitSize 32) or
+// xs:double (bitSize 64). Values are rounded to the nearest value of the
+// type; values too large for the type round to an infinity.
+func parseFloat(value string, bitSize int) (float64, error) {
+	if !floatRegexp.MatchString(value) {
+		return 0, fmt.Errorf("invalid floating-point number: %s", value)
+	}
+	switch value {
+	case "INF", "+INF":
+		return math.Inf(1), nil
+	case "-INF":
+		return math.Inf(-1), nil
+	case "NaN":
+		return math.NaN(), nil
+	}
+	f, err := strconv.ParseFloat(value, bitSize)
+	if err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("invalid floating-point number: %s", value)
+	}
+	return f, nil
+}
+
+// floatComparator returns the compareFunc of xs:float or xs:double. NaN is
+// incomparable with every value, including itself, and positive and negative
+// zero compare equal.
+func floatComparator(bitSize int) compareFunc {
+	return func(a, b string) (int, bool) {
+		x, err := parseFloat(a, bitSize)
+		if err != nil {
+			return 0, false
+		}
+		y, err := parseFloat(b, bitSize)
+		if err != nil {
+			return 0, false
+		}
+		switch {
+		case math.IsNaN(x) || math.IsNaN(y):
+			return 0, false
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		}
+		return 0, true
+	}
+}
+
+// floatEquality returns the equalFunc of xs:float or xs:double used for
+// enumeration and fixed values: NaN matches NaN, and positive and negative
+// zero match each other.
+func floatEquality(bitSize int) equalFunc {
+	return func(a, b string) bool {
+		x, err := parseFloat(a, bitSize)
+		if err != nil {
+			return false
+		}
+		y, err := parseFloat(b, bitSize)
+		if err != nil {
+			return false
+		}
+		return x == y || math.IsNaN(x) && math.IsNaN(y)
+	}
+}
+
+// decimalDigits returns the number of significant total and fraction digits
+// of a decimal value. Leading zeros of the integer part and trailing zeros of
+// the fraction part are not significant.
+func decimalDigits(value string) (int, int, error) {
+	if !decimalRegexp.MatchString(value) {
+		return 0, 0, fmt.Errorf("invalid decimal: %s", value)
+	}
+	digits := strings.TrimLeft(value, "+-")
+	integerPart, fractionPart, _ := strings.Cut(digits, ".")
+	integerPart = strings.TrimLeft(integerPart, "0")
+	fractionPart = strings.TrimRight(fractionPart, "0")
+
+	return len(integerPart) + len(fractionPart), len(fractionPart), nil
+}
@@ -0,0 +1,67 @@
+package pkg
+
+import "strings"
+
+// Option configures a Validator created by NewValidator.
+type Option func(*Validator)
+
+// WithXSDVersion selects the XML Schema language version used to compile the
+// schema. The default is XSDVersion10, under which XSD 1.1 constructs such as
+// xs:assert or xs:alternative are reported as compile errors. Under
+// XSDVersion11 those constructs are accepted but not yet evaluated.
+func WithXSDVersion(version XSDVersion) Option {
+	return func(v *Validator) {
+		v.version = version
+	}
+}
+
+// WithAbsoluteURIs rejects xs:anyURI values that are relative references.
+// By default any IRI reference is accepted.
+func WithAbsoluteURIs() Option {
+	return func(v *Validator) {
+		v.uris.absolute = true
+	}
+}
+
+// WithURISchemes restricts xs:anyURI values to absolute IRIs using one of the
+// given schemes. Schemes are compared case-insensitively.
+func WithURISchemes(schemes ...string) Option {
+	return func(v *Validator) {
+		v.uris.schemes = make(map[string]bool, len(schemes))
+		for _, scheme := range schemes {
+			v.uris.schemes[strings.ToLower(scheme)] = true
+		}
+	}
+}
+
+// WithSchemaResolver loads the schema documents imported with xs:import, so
+// that attribute references can name attributes declared in other
+// namespaces. Without a resolver imports are not loaded; attributes of the
+// xml namespace are always available.
+func WithSchemaResolver(resolver SchemaResolver) Option {
+	return func(v *Validator) {
+		v.resolver = resolver
+	}
+}
+
+// WithDefaults makes Validate return the document augmented with the values
+// the schema supplies: missing attributes with a default or fixed value are
+// added, and empty elements take the default or fixed value of their
+// declaration. Supplied values are marked as defaulted in the tree, which is
+// available as ValidationResult.Document and can be serialized with
+// WriteXML.
+func WithDefaults() Option {
+	return func(v *Validator) {
+		v.defaults = true
+	}
+}
+
+// WithInfoset makes Validate annotate the document with the outcome of
+// validation: every assessed element and attribute gets its type
+// definition, validity and typed value. The annotated tree is available as
+// ValidationResult.Document; see ElementInfo.
+func WithInfoset() Option {
+	return func(v *Validator) {
+		v.infoset = true
+	}
+}
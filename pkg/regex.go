@@ -0,0 +1,593 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// translatePattern translates a regular expression written in the XML Schema
+// dialect into an equivalent, anchored Go regular expression.
+//
+// XSD patterns always match the whole value, treat ^ and $ as ordinary
+// characters and support constructs that Go does not: the \i and \c escapes,
+// Unicode block escapes such as \p{IsBasicLatin} and character class
+// subtraction. Character classes are therefore computed as explicit rune
+// ranges. Perl extensions that XSD does not allow, such as anchors, word
+// boundaries, back-references, lazy quantifiers and groups starting with (?,
+// are rejected.
+func translatePattern(pattern string) (string, error) {
+	t := &patternTranslator{pattern: []rune(pattern)}
+	expr, err := t.parseRegExp()
+	if err != nil {
+		return "", err
+	}
+	if !t.done() {
+		return "", t.errorf("unmatched ')'")
+	}
+	return "^(?:" + expr + ")$", nil
+}
+
+// patternTranslator is a recursive descent parser for the XSD regular
+// expression grammar that emits Go regular expression syntax.
+type patternTranslator struct {
+	pattern []rune
+	pos     int
+}
+
+func (t *patternTranslator) done() bool {
+	return t.pos >= len(t.pattern)
+}
+
+func (t *patternTranslator) peek() rune {
+	if t.done() {
+		return 0
+	}
+	return t.pattern[t.pos]
+}
+
+func (t *patternTranslator) peekAt(offset int) rune {
+	if t.pos+offset >= len(t.pattern) {
+		return 0
+	}
+	return t.pattern[t.pos+offset]
+}
+
+func (t *patternTranslator) next() rune {
+	r := t.peek()
+	t.pos++
+	return r
+}
+
+func (t *patternTranslator) errorf(format string, args ...any) error {
+	return fmt.Errorf("at offset %d: %s", t.pos, fmt.Sprintf(format, args...))
+}
+
+// parseRegExp parses branches separated by '|'.
+func (t *patternTranslator) parseRegExp() (string, error) {
+	var branches []string
+	for {
+		branch, err := t.parseBranch()
+		if err != nil {
+			return "", err
+		}
+		branches = append(branches, branch)
+		if t.peek() != '|' || t.done() {
+			return strings.Join(branches, "|"), nil
+		}
+		t.next()
+	}
+}
+
+// parseBranch parses a sequence of pieces.
+func (t *patternTranslator) parseBranch() (string, error) {
+	var sb strings.Builder
+	for !t.done() && t.peek() != '|' && t.peek() != ')' {
+		piece, err := t.parsePiece()
+		if err != nil {
+			return "", err
+		}
+		sb.WriteString(piece)
+	}
+	return sb.String(), nil
+}
+
+// parsePiece parses an atom followed by an optional quantifier.
+func (t *patternTranslator) parsePiece() (string, error) {
+	atom, err := t.parseAtom()
+	if err != nil {
+		return "", err
+	}
+
+	quantifier, err := t.parseQuantifier()
+	if err != nil {
+		return "", err
+	}
+	if quantifier != "" && strings.ContainsRune("?*+{", t.peek()) {
+		return "", t.errorf("quantifier %q cannot follow another quantifier", t.peek())
+	}
+	return atom + quantifier, nil
+}
+
+func (t *patternTranslator) parseQuantifier() (string, error) {
+	if t.done() {
+		return "", nil
+	}
+	switch t.peek() {
+	case '?', '*', '+':
+		return string(t.next()), nil
+	case '{':
+		start := t.pos
+		t.next()
+		minCount := t.digits()
+		if minCount == "" {
+			return "", t.errorf("quantifier must start with a number")
+		}
+		quantifier := "{" + minCount
+		if t.peek() == ',' {
+			t.next()
+			quantifier += "," + t.digits()
+		}
+		if t.peek() != '}' || t.done() {
+			t.pos = start
+			return "", t.errorf("unterminated quantifier")
+		}
+		t.next()
+		return quantifier + "}", nil
+	}
+	return "", nil
+}
+
+func (t *patternTranslator) digits() string {
+	start := t.pos
+	for !t.done() && t.peek() >= '0' && t.peek() <= '9' {
+		t.next()
+	}
+	return string(t.pattern[start:t.pos])
+}
+
+func (t *patternTranslator) parseAtom() (string, error) {
+	r := t.next()
+	switch r {
+	case '(':
+		if t.peek() == '?' {
+			return "", t.errorf("groups starting with '(?' are not allowed")
+		}
+		inner, err := t.parseRegExp()
+		if err != nil {
+			return "", err
+		}
+		if t.done() || t.next() != ')' {
+			return "", t.errorf("missing ')'")
+		}
+		return "(?:" + inner + ")", nil
+	case '[':
+		set, err := t.parseCharClassExpr()
+		if err != nil {
+			return "", err
+		}
+		return set.String(), nil
+	case '.':
+		return newRuneSet('\n', '\n', '\r', '\r').negate().String(), nil
+	case '\\':
+		set, err := t.parseEscape()
+		if err != nil {
+			return "", err
+		}
+		return set.String(), nil
+	case '?', '*', '+', '{':
+		return "", t.errorf("quantifier %q without an atom", r)
+	case '}', ']':
+		return "", t.errorf("character %q must be escaped", r)
+	}
+	return regexp.QuoteMeta(string(r)), nil
+}
+
+// parseCharClassExpr parses a character class expression after its opening
+// '[', including negation and subtraction.
+func (t *patternTranslator) parseCharClassExpr() (runeSet, error) {
+	negated := false
+	if t.peek() == '^' {
+		t.next()
+		negated = true
+	}
+
+	set, err := t.parseCharGroup()
+	if err != nil {
+		return nil, err
+	}
+	if negated {
+		set = set.negate()
+	}
+
+	if t.peek() == '-' && t.peekAt(1) == '[' {
+		t.pos += 2
+		subtrahend, err := t.parseCharClassExpr()
+		if err != nil {
+			return nil, err
+		}
+		set = set.subtract(subtrahend)
+	}
+
+	if t.done() || t.next() != ']' {
+		return nil, t.errorf("missing ']'")
+	}
+	return set, nil
+}
+
+// parseCharGroup parses the ranges and escapes of a positive character group.
+func (t *patternTranslator) parseCharGroup() (runeSet, error) {
+	var set runeSet
+	first := true
+	for {
+		if t.done() {
+			return nil, t.errorf("missing ']'")
+		}
+		r := t.peek()
+		if r == ']' {
+			if first {
+				return nil, t.errorf("empty character group")
+			}
+			return set, nil
+		}
+		if r == '-' && t.peekAt(1) == '[' && !first {
+			return set, nil
+		}
+
+		start, err := t.parseCharOrEscape(first)
+		if err != nil {
+			return nil, err
+		}
+		first = false
+
+		if start.isMulti {
+			set = set.union(start.set)
+			continue
+		}
+		if t.peek() != '-' || t.peekAt(1) == ']' || t.peekAt(1) == '[' {
+			set = set.union(newRuneSet(start.char, start.char))
+			continue
+		}
+
+		// Character range
+		t.next()
+		end, err := t.parseCharOrEscape(false)
+		if err != nil {
+			return nil, err
+		}
+		if end.isMulti {
+			return nil, t.errorf("multi-character escape cannot end a range")
+		}
+		if end.char < start.char {
+			return nil, t.errorf("invalid range %q-%q", start.char, end.char)
+		}
+		set = set.union(newRuneSet(start.char, end.char))
+	}
+}
+
+// classAtom is either a single character or a multi-character escape
+// within a character group.
+type classAtom struct {
+	char    rune
+	set     runeSet
+	isMulti bool
+}
+
+func (t *patternTranslator) parseCharOrEscape(first bool) (classAtom, error) {
+	r := t.next()
+	switch r {
+	case '\\':
+		if single, ok := singleCharEscapes[t.peek()]; ok && !t.done() {
+			t.next()
+			return classAtom{char: single}, nil
+		}
+		set, err := t.parseEscape()
+		return classAtom{set: set, isMulti: true}, err
+	case '[':
+		return classAtom{}, t.errorf("'[' must be escaped in a character group")
+	case '-':
+		// A hyphen is literal at the start or the end of a group.
+		if !first && t.peek() != ']' {
+			return classAtom{}, t.errorf("'-' must be escaped in a character group")
+		}
+	}
+	return classAtom{char: r}, nil
+}
+
+var singleCharEscapes = map[rune]rune{
+	'n': '\n', 'r': '\r', 't': '\t',
+	'\\': '\\', '|': '|', '.': '.', '-': '-', '^': '^', '?': '?', '*': '*', '+': '+',
+	'{': '{', '}': '}', '(': '(', ')': ')', '[': '[', ']': ']',
+}
+
+// parseEscape parses an escape after its backslash.
+func (t *patternTranslator) parseEscape() (runeSet, error) {
+	if t.done() {
+		return nil, t.errorf("trailing backslash")
+	}
+	r := t.next()
+	if single, ok := singleCharEscapes[r]; ok {
+		return newRuneSet(single, single), nil
+	}
+
+	switch r {
+	case 's':
+		return spaceSet, nil
+	case 'S':
+		return spaceSet.negate(), nil
+	case 'i':
+		return nameStartSet(), nil
+	case 'I':
+		return nameStartSet().negate(), nil
+	case 'c':
+		return nameCharSet(), nil
+	case 'C':
+		return nameCharSet().negate(), nil
+	case 'd':
+		return tableSet(unicode.Nd), nil
+	case 'D':
+		return tableSet(unicode.Nd).negate(), nil
+	case 'w':
+		return wordSet(), nil
+	case 'W':
+		return wordSet().negate(), nil
+	case 'p', 'P':
+		if t.next() != '{' {
+			return nil, t.errorf("expected '{' after \\%c", r)
+		}
+		start := t.pos
+		for !t.done() && t.peek() != '}' {
+			t.next()
+		}
+		if t.done() {
+			return nil, t.errorf("missing '}' in \\%c escape", r)
+		}
+		name := string(t.pattern[start:t.pos])
+		t.next()
+
+		set, err := propertySet(name)
+		if err != nil {
+			return nil, t.errorf("%v", err)
+		}
+		if r == 'P' {
+			set = set.negate()
+		}
+		return set, nil
+	}
+	return nil, t.errorf("escape \\%c is not allowed in XSD patterns", r)
+}
+
+// propertySet returns the characters of a \p{...} category or block escape.
+func propertySet(name string) (runeSet, error) {
+	if block, ok := strings.CutPrefix(name, "Is"); ok {
+		r, ok := unicodeBlocks[block]
+		if !ok {
+			return nil, fmt.Errorf("unknown Unicode block %q", block)
+		}
+		return newRuneSet(r[0], r[1]), nil
+	}
+	table, ok := unicode.Categories[name]
+	if !ok || name == "LC" {
+		return nil, fmt.Errorf("unknown Unicode category %q", name)
+	}
+	return tableSet(table), nil
+}
+
+var (
+	spaceSet = newRuneSet(' ', ' ', '\t', '\t', '\n', '\n', '\r', '\r')
+	// The name character sets are built on first use to keep package
+	// initialization cheap.
+	nameStartSet = sync.OnceValue(func() runeSet { return predicateSet(isNameStartChar) })
+	nameCharSet  = sync.OnceValue(func() runeSet { return predicateSet(isNameChar) })
+)
+
+// wordSet returns the characters matched by \w: all characters except
+// punctuation, separators and other characters.
+func wordSet() runeSet {
+	return tableSet(unicode.P).union(tableSet(unicode.Z)).union(tableSet(unicode.C)).negate()
+}
+
+// runeSet is a sorted list of disjoint, non-adjacent inclusive rune ranges.
+type runeSet [][2]rune
+
+// newRuneSet builds a set from pairs of inclusive range bounds.
+func newRuneSet(bounds ...rune) runeSet {
+	var set runeSet
+	for i := 0; i+1 < len(bounds); i += 2 {
+		set = append(set, [2]rune{bounds[i], bounds[i+1]})
+	}
+	return set.normalize()
+}
+
+func tableSet(table *unicode.RangeTable) runeSet {
+	var set runeSet
+	add := func(lo, hi, stride rune) {
+		if stride == 1 {
+			set = append(set, [2]rune{lo, hi})
+			return
+		}
+		for c := lo; c <= hi; c += stride {
+			set = append(set, [2]rune{c, c})
+		}
+	}
+	for _, r := range table.R16 {
+		add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	for _, r := range table.R32 {
+		add(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+	}
+	return set.normalize()
+}
+
+func predicateSet(match func(rune) bool) runeSet {
+	var set runeSet
+	for r := rune(0); r <= unicode.MaxRune; r++ {
+		if !match(r) {
+			continue
+		}
+		if n := len(set); n > 0 && set[n-1][1] == r-1 {
+			set[n-1][1] = r
+		} else {
+			set = append(set, [2]rune{r, r})
+		}
+	}
+	return set
+}
+
+func (s runeSet) normalize() runeSet {
+	sorted := append(runeSet(nil), s...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i][0] < sorted[j][0] })
+
+	var out runeSet
+	for _, r := range sorted {
+		if n := len(out); n > 0 && r[0] <= out[n-1][1]+1 {
+			if r[1] > out[n-1][1] {
+				out[n-1][1] = r[1]
+			}
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+func (s runeSet) union(o runeSet) runeSet {
+	return append(append(runeSet(nil), s...), o...).normalize()
+}
+
+func (s runeSet) negate() runeSet {
+	var out runeSet
+	next := rune(0)
+	for _, r := range s {
+		if r[0] > next {
+			out = append(out, [2]rune{next, r[0] - 1})
+		}
+		next = r[1] + 1
+	}
+	if next <= unicode.MaxRune {
+		out = append(out, [2]rune{next, unicode.MaxRune})
+	}
+	return out
+}
+
+func (s runeSet) subtract(o runeSet) runeSet {
+	return s.negate().union(o).negate()
+}
+
+// String renders the set as a Go regular expression character class.
+func (s runeSet) String() string {
+	if len(s) == 0 {
+		return `[^\x{0}-\x{10FFFF}]`
+	}
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for _, r := range s {
+		fmt.Fprintf(&sb, `\x{%X}`, r[0])
+		if r[1] != r[0] {
+			fmt.Fprintf(&sb, `-\x{%X}`, r[1])
+		}
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// unicodeBlocks holds the Unicode blocks recognized by \p{Is...} escapes, as
+// listed by XML Schema Part 2.
+var unicodeBlocks = map[string][2]rune{
+	"BasicLatin":                           {0x0000, 0x007F},
+	"Latin-1Supplement":                    {0x0080, 0x00FF},
+	"LatinExtended-A":                      {0x0100, 0x017F},
+	"LatinExtended-B":                      {0x0180, 0x024F},
+	"IPAExtensions":                        {0x0250, 0x02AF},
+	"SpacingModifierLetters":               {0x02B0, 0x02FF},
+	"CombiningDiacriticalMarks":            {0x0300, 0x036F},
+	"Greek":                                {0x0370, 0x03FF},
+	"Cyrillic":                             {0x0400, 0x04FF},
+	"Armenian":                             {0x0530, 0x058F},
+	"Hebrew":                               {0x0590, 0x05FF},
+	"Arabic":                               {0x0600, 0x06FF},
+	"Syriac":                               {0x0700, 0x074F},
+	"Thaana":                               {0x0780, 0x07BF},
+	"Devanagari":                           {0x0900, 0x097F},
+	"Bengali":                              {0x0980, 0x09FF},
+	"Gurmukhi":                             {0x0A00, 0x0A7F},
+	"Gujarati":                             {0x0A80, 0x0AFF},
+	"Oriya":                                {0x0B00, 0x0B7F},
+	"Tamil":                                {0x0B80, 0x0BFF},
+	"Telugu":                               {0x0C00, 0x0C7F},
+	"Kannada":                              {0x0C80, 0x0CFF},
+	"Malayalam":                            {0x0D00, 0x0D7F},
+	"Sinhala":                              {0x0D80, 0x0DFF},
+	"Thai":                                 {0x0E00, 0x0E7F},
+	"Lao":                                  {0x0E80, 0x0EFF},
+	"Tibetan":                              {0x0F00, 0x0FFF},
+	"Myanmar":                              {0x1000, 0x109F},
+	"Georgian":                             {0x10A0, 0x10FF},
+	"HangulJamo":                           {0x1100, 0x11FF},
+	"Ethiopic":                             {0x1200, 0x137F},
+	"Cherokee":                             {0x13A0, 0x13FF},
+	"UnifiedCanadianAboriginalSyllabics":   {0x1400, 0x167F},
+	"Ogham":                                {0x1680, 0x169F},
+	"Runic":                                {0x16A0, 0x16FF},
+	"Khmer":                                {0x1780, 0x17FF},
+	"Mongolian":                            {0x1800, 0x18AF},
+	"LatinExtendedAdditional":              {0x1E00, 0x1EFF},
+	"GreekExtended":                        {0x1F00, 0x1FFF},
+	"GeneralPunctuation":                   {0x2000, 0x206F},
+	"SuperscriptsandSubscripts":            {0x2070, 0x209F},
+	"CurrencySymbols":                      {0x20A0, 0x20CF},
+	"CombiningMarksforSymbols":             {0x20D0, 0x20FF},
+	"LetterlikeSymbols":                    {0x2100, 0x214F},
+	"NumberForms":                          {0x2150, 0x218F},
+	"Arrows":                               {0x2190, 0x21FF},
+	"MathematicalOperators":                {0x2200, 0x22FF},
+	"MiscellaneousTechnical":               {0x2300, 0x23FF},
+	"ControlPictures":                      {0x2400, 0x243F},
+	"OpticalCharacterRecognition":          {0x2440, 0x245F},
+	"EnclosedAlphanumerics":                {0x2460, 0x24FF},
+	"BoxDrawing":                           {0x2500, 0x257F},
+	"BlockElements":                        {0x2580, 0x259F},
+	"GeometricShapes":                      {0x25A0, 0x25FF},
+	"MiscellaneousSymbols":                 {0x2600, 0x26FF},
+	"Dingbats":                             {0x2700, 0x27BF},
+	"BraillePatterns":                      {0x2800, 0x28FF},
+	"CJKRadicalsSupplement":                {0x2E80, 0x2EFF},
+	"KangxiRadicals":                       {0x2F00, 0x2FDF},
+	"IdeographicDescriptionCharacters":     {0x2FF0, 0x2FFF},
+	"CJKSymbolsandPunctuation":             {0x3000, 0x303F},
+	"Hiragana":                             {0x3040, 0x309F},
+	"Katakana":                             {0x30A0, 0x30FF},
+	"Bopomofo":                             {0x3100, 0x312F},
+	"HangulCompatibilityJamo":              {0x3130, 0x318F},
+	"Kanbun":                               {0x3190, 0x319F},
+	"BopomofoExtended":                     {0x31A0, 0x31BF},
+	"EnclosedCJKLettersandMonths":          {0x3200, 0x32FF},
+	"CJKCompatibility":                     {0x3300, 0x33FF},
+	"CJKUnifiedIdeographsExtensionA":       {0x3400, 0x4DB5},
+	"CJKUnifiedIdeographs":                 {0x4E00, 0x9FFF},
+	"YiSyllables":                          {0xA000, 0xA48F},
+	"YiRadicals":                           {0xA490, 0xA4CF},
+	"HangulSyllables":                      {0xAC00, 0xD7A3},
+	"PrivateUse":                           {0xE000, 0xF8FF},
+	"CJKCompatibilityIdeographs":           {0xF900, 0xFAFF},
+	"AlphabeticPresentationForms":          {0xFB00, 0xFB4F},
+	"ArabicPresentationForms-A":            {0xFB50, 0xFDFF},
+	"CombiningHalfMarks":                   {0xFE20, 0xFE2F},
+	"CJKCompatibilityForms":                {0xFE30, 0xFE4F},
+	"SmallFormVariants":                    {0xFE50, 0xFE6F},
+	"ArabicPresentationForms-B":            {0xFE70, 0xFEFE},
+	"Specials":                             {0xFFF0, 0xFFFD},
+	"HalfwidthandFullwidthForms":           {0xFF00, 0xFFEF},
+	"OldItalic":                            {0x10300, 0x1032F},
+	"Gothic":                               {0x10330, 0x1034F},
+	"Deseret":                              {0x10400, 0x1044F},
+	"ByzantineMusicalSymbols":              {0x1D000, 0x1D0FF},
+	"MusicalSymbols":                       {0x1D100, 0x1D1FF},
+	"MathematicalAlphanumericSymbols":      {0x1D400, 0x1D7FF},
+	"CJKUnifiedIdeographsExtensionB":       {0x20000, 0x2A6D6},
+	"CJKCompatibilityIdeographsSupplement": {0x2F800, 0x2FA1F},
+	"Tags":                                 {0xE0000, 0xE007F},
+}
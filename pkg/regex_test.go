@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestTranslatePattern(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		matches []string
+		rejects []string
+		invalid bool
+	}{
+		{
+			name:    "Anchored Match",
+			pattern: "[A-Z]{2}",
+			matches: []string{"AB"},
+			rejects: []string{"xxAByy", "ABC"},
+		},
+		{
+			name:    "Caret And Dollar Are Literals",
+			pattern: `^\d+$`,
+			matches: []string{"^42$"},
+			rejects: []string{"42"},
+		},
+		{
+			name:    "Dot Excludes Line Breaks",
+			pattern: "a.c",
+			matches: []string{"abc", "a c"},
+			rejects: []string{"a\nc", "a\rc"},
+		},
+		{
+			name:    "Character Class Subtraction",
+			pattern: "[a-z-[aeiou]]+",
+			matches: []string{"bcd"},
+			rejects: []string{"bad"},
+		},
+		{
+			name:    "Subtraction From Escape",
+			pattern: `[\s-[\t]]`,
+			matches: []string{" ", "\n"},
+			rejects: []string{"\t", "x"},
+		},
+		{
+			name:    "Name Escapes",
+			pattern: `\i\c*`,
+			matches: []string{"_name-1.x", "élan"},
+			rejects: []string{"1abc", "-abc"},
+		},
+		{
+			name:    "Non Name Escapes",
+			pattern: `\I\C`,
+			matches: []string{"1 "},
+			rejects: []string{"ab"},
+		},
+		{
+			name:    "Block Escape",
+			pattern: `\p{IsBasicLatin}+`,
+			matches: []string{"Hello"},
+			rejects: []string{"Hellö"},
+		},
+		{
+			name:    "Category Escape",
+			pattern: `\p{Lu}\P{Lu}*`,
+			matches: []string{"Ångström"},
+			rejects: []string{"aB"},
+		},
+		{
+			name:    "Hyphen At Group Edges",
+			pattern: "[-a]+[b-]+",
+			matches: []string{"-ab-"},
+		},
+		{
+			name:    "Escaped Metacharacters",
+			pattern: `\+\d{1,3}-\(\d\)`,
+			matches: []string{"+90-(5)"},
+		},
+		{
+			name:    "Alternation",
+			pattern: "cat|dog",
+			matches: []string{"cat", "dog"},
+			rejects: []string{"catdog"},
+		},
+		{name: "Word Boundary Rejected", pattern: `\bword`, invalid: true},
+		{name: "Back Reference Rejected", pattern: `(a)\1`, invalid: true},
+		{name: "Non Capturing Group Rejected", pattern: `(?:a)`, invalid: true},
+		{name: "Lazy Quantifier Rejected", pattern: `a+?`, invalid: true},
+		{name: "Hex Escape Rejected", pattern: `\x41`, invalid: true},
+		{name: "Unknown Block Rejected", pattern: `\p{IsKlingon}`, invalid: true},
+		{name: "Unescaped Bracket In Group Rejected", pattern: `[[:alpha:]]`, invalid: true},
+		{name: "Unbalanced Parenthesis Rejected", pattern: `a)`, invalid: true},
+		{name: "Empty Group Rejected", pattern: `[]`, invalid: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			translated, err := translatePattern(tc.pattern)
+			if tc.invalid {
+				if err == nil {
+					t.Fatalf("Expected pattern %q to be rejected, got %q", tc.pattern, translated)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to translate pattern %q: %v", tc.pattern, err)
+			}
+			re, err := regexp.Compile(translated)
+			if err != nil {
+				t.Fatalf("Translated pattern %q does not compile: %v", translated, err)
+			}
+			for _, value := range tc.matches {
+				if !re.MatchString(value) {
+					t.Errorf("Expected %q to match pattern %q", value, tc.pattern)
+				}
+			}
+			for _, value := range tc.rejects {
+				if re.MatchString(value) {
+					t.Errorf("Expected %q not to match pattern %q", value, tc.pattern)
+				}
+			}
+		})
+	}
+}
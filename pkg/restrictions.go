@@ -2,105 +2,185 @@ package pkg
 
 import (
 	"fmt"
-	"regexp"
 	"strconv"
+	"strings"
 	"unicode/utf8"
 )
 
-func (v *Validator) validateRestrictions(value string, baseType string, restrictions *XSDRestriction) error {
-	// Length restrictions
+func (v *Validator) validateRestrictions(value string, baseType string, restrictions *XSDRestriction, scope map[string]string) error {
+	// Length restrictions. For list types the length is the number of items
+	// and for binary types the number of octets.
+	primitive := v.primitiveType(baseType)
+	actualLen := utf8.RuneCountInString(value)
+	switch {
+	case v.isListType(baseType):
+		actualLen = len(listItems(value))
+	case primitive == "hexBinary" || primitive == "base64Binary":
+		octets, err := decodeBinary(primitive, value)
+		if err != nil {
+			return err
+		}
+		actualLen = len(octets)
+	}
+
 	if restrictions.Length.Value != "" {
 		length, _ := strconv.Atoi(restrictions.Length.Value)
-		actualLen := utf8.RuneCountInString(value)
 		if actualLen != length {
-			return fmt.Errorf("length must be exactly %d, got %d", length, actualLen)
+			return violation("cvc-length-valid", restrictions.Length.Value, strconv.Itoa(actualLen),
+				"length must be exactly %d, got %d", length, actualLen)
 		}
 	}
 
 	if restrictions.MinLength.Value != "" {
 		minLength, _ := strconv.Atoi(restrictions.MinLength.Value)
-		actualLen := utf8.RuneCountInString(value)
 		if actualLen < minLength {
-			return fmt.Errorf("length must be at least %d, got %d", minLength, actualLen)
+			return violation("cvc-minLength-valid", restrictions.MinLength.Value, strconv.Itoa(actualLen),
+				"length must be at least %d, got %d", minLength, actualLen)
 		}
 	}
 
 	if restrictions.MaxLength.Value != "" {
 		maxLength, _ := strconv.Atoi(restrictions.MaxLength.Value)
-		actualLen := utf8.RuneCountInString(value)
 		if actualLen > maxLength {
-			return fmt.Errorf("length must be at most %d, got %d", maxLength, actualLen)
+			return violation("cvc-maxLength-valid", restrictions.MaxLength.Value, strconv.Itoa(actualLen),
+				"length must be at most %d, got %d", maxLength, actualLen)
 		}
 	}
 
-	// Numeric restrictions
-	switch baseType {
-	case "xs:decimal", "xs:integer", "xs:float", "xs:double":
-		num, _ := strconv.ParseFloat(value, 64)
-
-		if restrictions.MinInclusive.Value != "" {
-			minInclusive, err := strconv.ParseFloat(restrictions.MinInclusive.Value, 64)
-			if err == nil && num < minInclusive {
-				return fmt.Errorf("value must be >= %v, got %v", minInclusive, num)
-			}
+	// Range restrictions, compared in the value space of the primitive type
+	if compare := comparator(primitive, v.version); compare != nil {
+		if err := checkRange(value, restrictions, compare); err != nil {
+			return err
 		}
-
-		if restrictions.MaxInclusive.Value != "" {
-			maxInclusive, err := strconv.ParseFloat(restrictions.MaxInclusive.Value, 64)
-			if err == nil && num > maxInclusive {
-				return fmt.Errorf("value must be <= %v, got %v", maxInclusive, num)
-			}
+	}
+	if primitive == "decimal" {
+		if err := checkDigits(value, restrictions); err != nil {
+			return err
 		}
+	}
 
-		if restrictions.MinExclusive.Value != "" {
-			minExclusive, err := strconv.ParseFloat(restrictions.MinExclusive.Value, 64)
-			if err == nil && num <= minExclusive {
-				return fmt.Errorf("value must be > %v, got %v", minExclusive, num)
+	// Pattern restrictions. Patterns of the same restriction step are
+	// alternatives: the value must match at least one of them.
+	if len(restrictions.Pattern) > 0 {
+		matched := false
+		values := make([]string, 0, len(restrictions.Pattern))
+		for _, pattern := range restrictions.Pattern {
+			re, err := v.patterns.Compile(pattern.Value)
+			if err != nil {
+				return fmt.Errorf("invalid pattern: %s", pattern.Value)
 			}
-		}
-
-		if restrictions.MaxExclusive.Value != "" {
-			maxExclusive, err := strconv.ParseFloat(restrictions.MaxExclusive.Value, 64)
-			if err == nil && num >= maxExclusive {
-				return fmt.Errorf("value must be < %v, got %v", maxExclusive, num)
+			if re.MatchString(value) {
+				matched = true
+				break
 			}
-		}
-	}
-
-	// Pattern restrictions
-	for _, pattern := range restrictions.Pattern {
-		matched, err := regexp.MatchString(pattern.Value, value)
-		if err != nil {
-			return fmt.Errorf("invalid pattern: %s", pattern.Value)
+			values = append(values, pattern.Value)
 		}
 		if !matched {
-			return fmt.Errorf("value does not match pattern: %s", pattern.Value)
+			return violation("cvc-pattern-valid", strings.Join(values, " | "), value,
+				"value does not match pattern: %s", strings.Join(values, " | "))
 		}
 	}
 
-	// Enumeration restrictions
+	// Enumeration restrictions, compared in the value space of the base type.
+	// Enumeration values are normalized the same way as the value.
 	if len(restrictions.Enumeration) > 0 {
 		valid := false
+		values := make([]string, 0, len(restrictions.Enumeration))
+		whiteSpace := v.whiteSpace(baseType, restrictions)
 		for _, enum := range restrictions.Enumeration {
-			if value == enum.Value {
+			if v.valuesEqual(baseType, value, normalizeWhiteSpace(enum.Value, whiteSpace), scope) {
 				valid = true
 				break
 			}
+			values = append(values, enum.Value)
 		}
 		if !valid {
-			return fmt.Errorf("value must be one of the enumerated values")
+			return violation("cvc-enumeration-valid", strings.Join(values, ", "), value,
+				"value '%s' must be one of: %s", value, strings.Join(values, ", "))
 		}
 	}
 
 	return nil
 }
 
-// Helper function for duration validation
-func validateDuration(value string) error {
-	// Duration format: -?P([0-9]+Y)?([0-9]+M)?([0-9]+D)?(T([0-9]+H)?([0-9]+M)?([0-9]+(\.[0-9]+)?S)?)?
-	pattern := `^-?P(([0-9]+Y)?([0-9]+M)?([0-9]+D)?)?(T([0-9]+H)?([0-9]+M)?([0-9]+(\.[0-9]+)?S)?)?$`
-	if matched, _ := regexp.MatchString(pattern, value); !matched {
-		return fmt.Errorf("invalid duration format")
+// compareFunc compares two lexical values in the value space of a type. It
+// returns false if either value is invalid or the values are incomparable.
+type compareFunc func(a, b string) (int, bool)
+
+// comparator returns the function comparing values of a primitive type
+// under an XSD version, or nil if the range facets are not supported for the
+// type.
+func comparator(primitive string, version XSDVersion) compareFunc {
+	switch primitive {
+	case "decimal":
+		return compareDecimal
+	case "float":
+		return floatComparator(32)
+	case "double":
+		return floatComparator(64)
+	case "duration":
+		return compareDuration
+	case "dateTime", "date", "time", "gYearMonth", "gYear", "gMonthDay", "gDay", "gMonth":
+		return dateTimeComparator(primitive, version)
+	}
+	return nil
+}
+
+// checkRange applies the minInclusive, maxInclusive, minExclusive and
+// maxExclusive facets to a value.
+func checkRange(value string, restrictions *XSDRestriction, compare compareFunc) error {
+	if bound := restrictions.MinInclusive.Value; bound != "" {
+		if c, ok := compare(value, bound); !ok || c < 0 {
+			return violation("cvc-minInclusive-valid", bound, value, "value must be >= %s, got %s", bound, value)
+		}
 	}
+
+	if bound := restrictions.MaxInclusive.Value; bound != "" {
+		if c, ok := compare(value, bound); !ok || c > 0 {
+			return violation("cvc-maxInclusive-valid", bound, value, "value must be <= %s, got %s", bound, value)
+		}
+	}
+
+	if bound := restrictions.MinExclusive.Value; bound != "" {
+		if c, ok := compare(value, bound); !ok || c <= 0 {
+			return violation("cvc-minExclusive-valid", bound, value, "value must be > %s, got %s", bound, value)
+		}
+	}
+
+	if bound := restrictions.MaxExclusive.Value; bound != "" {
+		if c, ok := compare(value, bound); !ok || c >= 0 {
+			return violation("cvc-maxExclusive-valid", bound, value, "value must be < %s, got %s", bound, value)
+		}
+	}
+
+	return nil
+}
+
+// checkDigits applies the totalDigits and fractionDigits facets to a decimal value.
+func checkDigits(value string, restrictions *XSDRestriction) error {
+	if restrictions.TotalDigits.Value == "" && restrictions.FractionDigits.Value == "" {
+		return nil
+	}
+	total, fraction, err := decimalDigits(value)
+	if err != nil {
+		return err
+	}
+
+	if restrictions.TotalDigits.Value != "" {
+		totalDigits, _ := strconv.Atoi(restrictions.TotalDigits.Value)
+		if total > totalDigits {
+			return violation("cvc-totalDigits-valid", restrictions.TotalDigits.Value, strconv.Itoa(total),
+				"value must have at most %d total digits, got %d", totalDigits, total)
+		}
+	}
+
+	if restrictions.FractionDigits.Value != "" {
+		fractionDigits, _ := strconv.Atoi(restrictions.FractionDigits.Value)
+		if fraction > fractionDigits {
+			return violation("cvc-fractionDigits-valid", restrictions.FractionDigits.Value, strconv.Itoa(fraction),
+				"value must have at most %d fraction digits, got %d", fractionDigits, fraction)
+		}
+	}
+
 	return nil
 }
@@ -11,6 +11,13 @@ type ValidationResult struct {
 	Valid    bool     `json:"valid"`
 	Filename string   `json:"filename"`
 	Errors   []string `json:"errors,omitempty"`
+	// Details describes every error in Errors, in the same order.
+	Details []ValidationError `json:"details,omitempty"`
+	// Document is the validated document tree. It is only set when the
+	// validator is created WithDefaults, which augments it with default and
+	// fixed values, or WithInfoset, which annotates it with the outcome of
+	// validation. ValidateStream never sets it.
+	Document *XMLNode `json:"-"`
 }
 
 // OutputResult is responsible on output formatting
@@ -29,8 +36,12 @@ func (r *ValidationResult) OutputResult(format string) {
 			fmt.Printf("✓ XML file '%s' is valid\n", r.Filename)
 		} else {
 			fmt.Printf("✗ XML file '%s' is invalid:\n", r.Filename)
-			for _, err := range r.Errors {
-				fmt.Printf("  - %s\n", err)
+			for i, err := range r.Errors {
+				if i < len(r.Details) && r.Details[i].Line > 0 {
+					fmt.Printf("  - %s:%d:%d: %s\n", r.Filename, r.Details[i].Line, r.Details[i].Column, err)
+				} else {
+					fmt.Printf("  - %s\n", err)
+				}
 			}
 		}
 	}
@@ -0,0 +1,104 @@
+package pkg
+
+import (
+	"fmt"
+	"io"
+)
+
+// streamFrame is an open element during streaming validation, with the
+// states of validating it against each of the declarations it matched.
+type streamFrame struct {
+	states []*elementState
+	// simpleContent reports whether the element is validated against a
+	// simple type, so that its character data must be kept.
+	simpleContent bool
+}
+
+// ValidateStream checks an XML document against the XSD schema while it is
+// read, without building the document tree. Every element is validated
+// against the content model of its parent as soon as its start tag is read
+// and its content once its end tag is read, so memory use is proportional
+// to the depth of the document rather than its size: only the open elements
+// are kept, and only elements of a simple type keep their character data.
+//
+// The result reports the same errors as Validate, though not always in the
+// same order: each error is reported as soon as the tag it concerns is read,
+// while Validate assesses an element against each of its declarations in
+// turn. Identity constraints and assertions are not evaluated by either, so
+// nothing else is buffered. Default values and the infoset are not
+// available: ValidationResult.Document is never set.
+func (v *Validator) ValidateStream(xmlFile io.Reader) (*ValidationResult, error) {
+	scanner := newXMLScanner(xmlFile)
+	var frames []*streamFrame
+	scanner.keepText = func(*XMLNode) bool {
+		return frames[len(frames)-1].simpleContent
+	}
+
+	result := &ValidationResult{Valid: true}
+	report := func(errors []ValidationError) {
+		for _, e := range errors {
+			result.Details = append(result.Details, e)
+			result.Errors = append(result.Errors, e.Message)
+		}
+	}
+
+	for {
+		node, ended, err := scanner.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse XML: %v", err)
+		}
+
+		if ended {
+			frame := frames[len(frames)-1]
+			frames = frames[:len(frames)-1]
+			for _, state := range frame.states {
+				report(v.endElement(node, state))
+			}
+			continue
+		}
+
+		// Find the declarations of the element: the global declaration of
+		// the root element, or those the content models of its parent assign.
+		var decls []XSDElement
+		if len(frames) == 0 {
+			rootXsd := v.findSchemaElementNS(node.Name, node.Namespace, v.schema.Elements)
+			if rootXsd == nil {
+				return nil, fmt.Errorf("root element '{%s}%s' not defined in schema", node.Namespace, node.Name)
+			}
+			result.Filename = node.Name
+			decls = append(decls, *rootXsd)
+		} else {
+			for _, state := range frames[len(frames)-1].states {
+				childDecls, errors := v.childDeclarations(state, node)
+				report(errors)
+				decls = append(decls, childDecls...)
+			}
+		}
+
+		frame := &streamFrame{}
+		for _, decl := range decls {
+			state, errors := v.startElement(node, decl)
+			report(errors)
+			if state == nil {
+				continue
+			}
+			frame.states = append(frame.states, state)
+			if state.decl.SimpleType != nil || state.decl.Type != "" && v.isSimpleType(state.decl.Type) {
+				frame.simpleContent = true
+			}
+		}
+		frames = append(frames, frame)
+	}
+
+	if result.Filename == "" {
+		return nil, fmt.Errorf("failed to parse XML: no root element")
+	}
+	// If any validation errors are found, mark the XML as invalid.
+	if len(result.Errors) > 0 {
+		result.Valid = false
+	}
+	return result, nil
+}
@@ -2,12 +2,16 @@ package pkg
 
 import (
 	"fmt"
-	"regexp"
-	"strconv"
-	"time"
+	"strings"
 )
 
-func (v *Validator) findSimpleType(name string) *XSDSimpleType {
+// findSimpleType returns the simple type definition a type reference names,
+// or nil if the schema does not define it.
+func (v *Validator) findSimpleType(typeName string) *XSDSimpleType {
+	name, ok := v.localTypeName(typeName)
+	if !ok {
+		return nil
+	}
 	for i, st := range v.schema.SimpleTypes {
 		if st.Name == name {
 			return &v.schema.SimpleTypes[i]
@@ -16,7 +20,13 @@ func (v *Validator) findSimpleType(name string) *XSDSimpleType {
 	return nil
 }
 
-func (v *Validator) findComplexType(name string) *XSDComplexType {
+// findComplexType returns the complex type definition a type reference
+// names, or nil if the schema does not define it.
+func (v *Validator) findComplexType(typeName string) *XSDComplexType {
+	name, ok := v.localTypeName(typeName)
+	if !ok {
+		return nil
+	}
 	for i, ct := range v.schema.ComplexTypes {
 		if ct.Name == name {
 			return &v.schema.ComplexTypes[i]
@@ -25,111 +35,173 @@ func (v *Validator) findComplexType(name string) *XSDComplexType {
 	return nil
 }
 
-// validateType verifies that a value conforms to the given XSD type.
-func (v *Validator) validateType(value string, typeName string, restrictions *XSDRestriction) error {
-	// First validate base type
-	if err := v.validateBaseType(value, typeName); err != nil {
-		return err
+// localTypeName resolves a reference to a type defined in the schema to the
+// local name of the type. Prefixed references must be bound to the target
+// namespace in the schema document. Unprefixed references are in the default
+// namespace, or in the target namespace if the schema document does not
+// declare a default namespace.
+func (v *Validator) localTypeName(typeName string) (string, bool) {
+	prefix, local := splitQName(typeName)
+	uri, bound := v.namespaces[prefix]
+	if !bound {
+		return local, prefix == ""
 	}
+	return local, uri == v.schema.TargetNS
+}
 
-	// Then apply any restrictions
-	if restrictions != nil {
-		if err := v.validateRestrictions(value, typeName, restrictions); err != nil {
-			return err
+// primitiveType returns the built-in primitive type a type reference is
+// derived from, or an empty string if it cannot be resolved.
+func (v *Validator) primitiveType(typeName string) string {
+	f, err := v.effectiveFacets(typeName)
+	if err != nil {
+		return ""
+	}
+	return f.primitive
+}
+
+// isSimpleType reports whether a type reference resolves to a built-in or
+// user-defined simple type.
+func (v *Validator) isSimpleType(typeName string) bool {
+	if _, ok := v.builtinTypeName(typeName); ok {
+		return true
+	}
+	return v.findSimpleType(typeName) != nil
+}
+
+// whiteSpace returns the effective whiteSpace facet value of a type.
+func (v *Validator) whiteSpace(typeName string, restrictions *XSDRestriction) string {
+	if restrictions != nil && restrictions.WhiteSpace.Value != "" {
+		return restrictions.WhiteSpace.Value
+	}
+	if name, ok := v.builtinTypeName(typeName); ok {
+		return builtinWhiteSpace(name)
+	}
+	if st := v.findSimpleType(typeName); st != nil {
+		switch {
+		case st.Restriction != nil:
+			return v.whiteSpace(st.Restriction.Base, st.Restriction)
+		case st.List != nil:
+			return whiteSpaceCollapse
 		}
 	}
+	return whiteSpacePreserve
+}
 
-	return nil
+// isListType reports whether a type reference resolves to a list type, either
+// a built-in list type or a user-defined list or restriction of one.
+func (v *Validator) isListType(typeName string) bool {
+	if name, ok := v.builtinTypeName(typeName); ok {
+		return builtinTypes[name].itemType != ""
+	}
+	st := v.findSimpleType(typeName)
+	switch {
+	case st == nil:
+		return false
+	case st.List != nil:
+		return true
+	case st.Restriction != nil:
+		return v.isListType(st.Restriction.Base)
+	}
+	return false
 }
 
-func (v *Validator) validateBaseType(value string, typeName string) error { //nolint:gocyclo
-	switch typeName {
-	case "xs:string", "string":
-		return nil // Always valid
+// validateSimpleType verifies that a value conforms to an anonymous or named
+// simple type definition.
+func (v *Validator) validateSimpleType(value string, simpleType *XSDSimpleType, scope map[string]string) error {
+	switch {
+	case simpleType.Restriction != nil:
+		return v.validateType(value, simpleType.Restriction.Base, simpleType.Restriction, scope)
+	case simpleType.List != nil:
+		return v.validateList(value, simpleType.List, scope)
+	case simpleType.Union != nil:
+		_, err := v.matchUnionMember(value, simpleType.Union, scope)
+		return err
+	}
+	return fmt.Errorf("simple type %s has no restriction", simpleType.Name)
+}
 
-	case "xs:integer", "integer":
-		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
-			return fmt.Errorf("invalid integer value: %s", value)
-		}
-	case "xs:int", "int":
-		if _, err := strconv.ParseInt(value, 10, 32); err != nil {
-			return fmt.Errorf("invalid int value: %s", value)
-		}
-	case "xs:positiveInteger":
-		val, err := strconv.ParseInt(value, 10, 64)
-		if err != nil {
-			return fmt.Errorf("invalid positive integer value: %s", value)
-		}
-		if val <= 0 {
-			return fmt.Errorf("value must be positive, got %d", val)
-		}
-	case "xs:decimal", "decimal":
-		if _, err := strconv.ParseFloat(value, 64); err != nil {
-			return fmt.Errorf("invalid decimal value: %s", value)
-		}
-	case "xs:float", "float":
-		if _, err := strconv.ParseFloat(value, 32); err != nil {
-			return fmt.Errorf("invalid float value: %s", value)
-		}
-	case "xs:double", "double":
-		if _, err := strconv.ParseFloat(value, 64); err != nil {
-			return fmt.Errorf("invalid double value: %s", value)
-		}
-	case "xs:long", "long":
-		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
-			return fmt.Errorf("invalid long value: %s", value)
-		}
-	case "xs:boolean", "boolean":
-		if value != "true" && value != "false" && value != "1" && value != "0" {
-			return fmt.Errorf("invalid boolean value: %s", value)
-		}
-	case "xs:date", "date":
-		if _, err := time.Parse("2006-01-02", value); err != nil {
-			return fmt.Errorf("invalid date value: %s", value)
-		}
-	case "xs:time", "time":
-		if _, err := time.Parse("15:04:05", value); err != nil {
-			return fmt.Errorf("invalid time value: %s", value)
+// matchUnionMember tries the member types of a union in order, the types of
+// the memberTypes attribute first and then the anonymous member types, and
+// returns the name of the first member type the value is valid for.
+// Anonymous member types are named by their position in the union.
+func (v *Validator) matchUnionMember(value string, union *XSDUnion, scope map[string]string) (string, error) {
+	members := union.MemberTypeNames()
+	for _, member := range members {
+		if err := v.validateType(value, member, nil, scope); err == nil {
+			return member, nil
 		}
-	case "xs:dateTime", "dateTime":
-		if _, err := time.Parse("2006-01-02T15:04:05", value); err != nil {
-			return fmt.Errorf("invalid dateTime value: %s", value)
-		}
-	case "xs:duration", "duration":
-		if err := validateDuration(value); err != nil {
-			return fmt.Errorf("invalid duration value: %s", value)
-		}
-	case "xs:gYear", "gYear":
-		if matched, _ := regexp.MatchString(`^-?\d{4}$`, value); !matched {
-			return fmt.Errorf("invalid gYear value: %s", value)
-		}
-	case "xs:gYearMonth", "gYearMonth":
-		if matched, _ := regexp.MatchString(`^-?\d{4}-\d{2}$`, value); !matched {
-			return fmt.Errorf("invalid gYearMonth value: %s", value)
-		}
-	case "xs:hexBinary", "hexBinary":
-		if matched, _ := regexp.MatchString(`^[0-9a-fA-F]*$`, value); !matched {
-			return fmt.Errorf("invalid hexBinary value: %s", value)
-		}
-	case "xs:base64Binary", "base64Binary":
-		if matched, _ := regexp.MatchString(`^[A-Za-z0-9+/]*={0,2}$`, value); !matched {
-			return fmt.Errorf("invalid base64Binary value: %s", value)
+	}
+	for i := range union.SimpleTypes {
+		if err := v.validateSimpleType(value, &union.SimpleTypes[i], scope); err == nil {
+			return fmt.Sprintf("#%d", len(members)+i+1), nil
 		}
-	case "xs:anyURI", "anyURI":
-		if matched, _ := regexp.MatchString(`^[a-zA-Z][a-zA-Z0-9+.-]*:`, value); !matched {
-			return fmt.Errorf("invalid anyURI value: %s", value)
+	}
+
+	candidates := members
+	for i := range union.SimpleTypes {
+		candidates = append(candidates, fmt.Sprintf("#%d", len(members)+i+1))
+	}
+	return "", violation("cvc-datatype-valid.1.2.3", strings.Join(candidates, ", "), value,
+		"value '%s' does not match any member type of the union (%s)", value, strings.Join(candidates, ", "))
+}
+
+// validateList splits a list value on whitespace and validates every item
+// against the item type of the list.
+func (v *Validator) validateList(value string, list *XSDList, scope map[string]string) error {
+	for _, item := range listItems(value) {
+		var err error
+		if list.SimpleType != nil {
+			err = v.validateSimpleType(item, list.SimpleType, scope)
+		} else {
+			err = v.validateType(item, list.ItemType, nil, scope)
 		}
-	default:
-		// Not a built-in type. Try to resolve it as a user-defined simple type.
-		simpleType := v.findSimpleType(typeName)
-		if simpleType == nil {
-			return fmt.Errorf("unsupported type: %s", typeName)
+		if err != nil {
+			return fmt.Errorf("invalid list item '%s': %w", item, err)
 		}
-		// Validate using the simple type's restriction.
-		if simpleType.Restriction != nil {
-			return v.validateType(value, simpleType.Restriction.Base, simpleType.Restriction)
+	}
+	return nil
+}
+
+// listItems returns the items of a list value.
+func listItems(value string) []string {
+	value = normalizeWhiteSpace(value, whiteSpaceCollapse)
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, " ")
+}
+
+// validateType verifies that a value conforms to the given XSD type. Prefixes
+// in QName and NOTATION values are resolved against the namespace bindings in
+// scope.
+func (v *Validator) validateType(value string, typeName string, restrictions *XSDRestriction, scope map[string]string) error {
+	// Normalize whitespace before any lexical or facet checks
+	value = normalizeWhiteSpace(value, v.whiteSpace(typeName, restrictions))
+
+	// First validate base type
+	if err := v.validateBaseType(value, typeName, scope); err != nil {
+		return err
+	}
+
+	// Then apply any restrictions
+	if restrictions != nil {
+		if err := v.validateRestrictions(value, typeName, restrictions, scope); err != nil {
+			return err
 		}
-		return fmt.Errorf("simple type %s has no restriction", typeName)
 	}
+
 	return nil
 }
+
+func (v *Validator) validateBaseType(value string, typeName string, scope map[string]string) error {
+	if name, ok := v.builtinTypeName(typeName); ok {
+		return v.validateBuiltinType(value, name, scope)
+	}
+
+	// Not a built-in type. Try to resolve it as a user-defined simple type.
+	simpleType := v.findSimpleType(typeName)
+	if simpleType == nil {
+		return fmt.Errorf("unsupported type: %s", typeName)
+	}
+	return v.validateSimpleType(value, simpleType, scope)
+}
@@ -0,0 +1,176 @@
+package pkg
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	schemeRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9+.-]*$`)
+	portRegexp   = regexp.MustCompile(`^[0-9]*$`)
+)
+
+// uriPolicy holds the optional restrictions on xs:anyURI values configured
+// with WithAbsoluteURIs and WithURISchemes.
+type uriPolicy struct {
+	absolute bool
+	// schemes holds the allowed schemes in lower case. A nil map allows
+	// every scheme.
+	schemes map[string]bool
+}
+
+// checkAnyURI accepts the IRI references of RFC 3987: absolute IRIs as well
+// as relative references such as "../a.xml" or "#frag".
+func checkAnyURI(value string) error {
+	_, err := parseIRIReference(value)
+	return err
+}
+
+// parseIRIReference checks the syntax of an IRI reference and returns its
+// scheme, or an empty string for a relative reference.
+func parseIRIReference(value string) (string, error) {
+	for i := 0; i < len(value); i++ {
+		if value[i] == '%' && (i+2 >= len(value) || !isHexDigit(value[i+1]) || !isHexDigit(value[i+2])) {
+			return "", fmt.Errorf("invalid percent-encoding in IRI: %s", value)
+		}
+	}
+	for _, r := range value {
+		if !isIRIChar(r) && !isIPrivate(r) {
+			return "", fmt.Errorf("character %q is not allowed in an IRI: %s", r, value)
+		}
+	}
+
+	rest, fragment, _ := strings.Cut(value, "#")
+	if strings.Contains(fragment, "#") {
+		return "", fmt.Errorf("IRI has more than one fragment: %s", value)
+	}
+	rest, query, _ := strings.Cut(rest, "?")
+
+	// A colon before the first slash separates the scheme; a relative
+	// reference cannot have a colon in its first path segment.
+	scheme := ""
+	if i := strings.IndexAny(rest, ":/"); i >= 0 && rest[i] == ':' {
+		scheme, rest = rest[:i], rest[i+1:]
+		if !schemeRegexp.MatchString(scheme) {
+			return "", fmt.Errorf("invalid IRI scheme %q: %s", scheme, value)
+		}
+	}
+
+	path := rest
+	if authority, ok := strings.CutPrefix(rest, "//"); ok {
+		path = ""
+		if i := strings.IndexByte(authority, '/'); i >= 0 {
+			authority, path = authority[:i], authority[i:]
+		}
+		if err := checkAuthority(authority); err != nil {
+			return "", fmt.Errorf("%v: %s", err, value)
+		}
+	}
+
+	for _, part := range []string{path, query, fragment} {
+		if strings.ContainsAny(part, "[]") {
+			return "", fmt.Errorf("brackets are only allowed in the IRI host: %s", value)
+		}
+	}
+	// Private use characters are only allowed in the query.
+	if strings.IndexFunc(rest, isIPrivate) >= 0 || strings.IndexFunc(fragment, isIPrivate) >= 0 {
+		return "", fmt.Errorf("private use characters are only allowed in the IRI query: %s", value)
+	}
+	return scheme, nil
+}
+
+// checkAuthority checks the userinfo, host and port of an IRI authority.
+func checkAuthority(authority string) error {
+	if i := strings.LastIndexByte(authority, '@'); i >= 0 {
+		if strings.ContainsAny(authority[:i], "[]") {
+			return fmt.Errorf("brackets are not allowed in the IRI userinfo")
+		}
+		authority = authority[i+1:]
+	}
+
+	host, port := authority, ""
+	if strings.HasPrefix(authority, "[") {
+		end := strings.IndexByte(authority, ']')
+		if end < 0 {
+			return fmt.Errorf("unterminated IP literal in IRI host")
+		}
+		host, port = authority[:end+1], authority[end+1:]
+		if port != "" && !strings.HasPrefix(port, ":") {
+			return fmt.Errorf("invalid IRI host %s", authority)
+		}
+		port = strings.TrimPrefix(port, ":")
+	} else if i := strings.LastIndexByte(authority, ':'); i >= 0 {
+		host, port = authority[:i], authority[i+1:]
+	}
+
+	inner := host
+	if strings.HasPrefix(host, "[") {
+		inner = host[1 : len(host)-1]
+	}
+	if strings.ContainsAny(inner, "[]") {
+		return fmt.Errorf("invalid IRI host %s", host)
+	}
+	if !portRegexp.MatchString(port) {
+		return fmt.Errorf("invalid IRI port %s", port)
+	}
+	return nil
+}
+
+// isIRIChar reports whether a character may appear anywhere in an IRI
+// reference: the unreserved and reserved characters of RFC 3986, the percent
+// sign, and the non-ASCII characters of the ucschar production of RFC 3987.
+func isIRIChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	case strings.ContainsRune("-._~:/?#[]@!$&'()*+,;=%", r):
+		return true
+	case r < 0x80:
+		return false
+	}
+	return isUCSChar(r)
+}
+
+// isUCSChar reports whether a character is in the ucschar production of
+// RFC 3987.
+func isUCSChar(r rune) bool {
+	switch {
+	case r >= 0xA0 && r <= 0xD7FF, r >= 0xF900 && r <= 0xFDCF, r >= 0xFDF0 && r <= 0xFFEF:
+		return true
+	case r >= 0x10000 && r <= 0xEFFFD:
+		// Every plane except the last allows all but its two final code
+		// points; plane 14 only allows E1000-EFFFD.
+		return r&0xFFFE != 0xFFFE && (r < 0xE0000 || r >= 0xE1000)
+	}
+	return false
+}
+
+// isIPrivate reports whether a character is in the iprivate production of
+// RFC 3987.
+func isIPrivate(r rune) bool {
+	return r >= 0xE000 && r <= 0xF8FF || r >= 0xF0000 && r <= 0xFFFFD || r >= 0x100000 && r <= 0x10FFFD
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// check applies the restrictions configured for the validator to an anyURI
+// value.
+func (p uriPolicy) check(value string) error {
+	if !p.absolute && p.schemes == nil {
+		return nil
+	}
+	scheme, err := parseIRIReference(value)
+	if err != nil {
+		return err
+	}
+	if scheme == "" {
+		return fmt.Errorf("relative reference is not allowed")
+	}
+	if p.schemes != nil && !p.schemes[strings.ToLower(scheme)] {
+		return fmt.Errorf("scheme %s is not allowed", scheme)
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"math"
 	"strconv"
+	"strings"
 )
 
 // Validator is responsible for validating XML files against an XSD schema.
@@ -15,23 +16,92 @@ type Validator struct {
 	patterns   *PatternCache
 	namespaces map[string]string
 	defaultNS  string
+	version    XSDVersion
+	facets     map[string]*facets
+	uris       uriPolicy
+	resolver   SchemaResolver
+	// imports holds the validators of the imported schema documents, and
+	// loaded those of every schema document loaded so far by namespace. All
+	// the validators of a schema share loaded.
+	imports  []*Validator
+	loaded   map[string]*Validator
+	defaults bool
+	infoset  bool
 }
 
 // NewValidator initializes a Validator instance by parsing an XSD file.
-// It returns an error if the XSD cannot be parsed.
-func NewValidator(xsdFile io.Reader) (*Validator, error) {
+// It returns an error if the XSD cannot be parsed or uses constructs that are
+// not available in the selected XSD version.
+func NewValidator(xsdFile io.Reader, opts ...Option) (*Validator, error) {
+	v := &Validator{
+		patterns:   NewPatternCache(),
+		namespaces: make(map[string]string),
+		version:    XSDVersion10,
+		facets:     make(map[string]*facets),
+		loaded:     make(map[string]*Validator),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+
+	version, err := v.version.number()
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := v.parseSchema(xsdFile, version)
+	if err != nil {
+		return nil, err
+	}
+	if err := v.useSchema(schema, version); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// useSchema makes a parsed schema document the schema of the validator: it
+// records the namespace bindings of the document, loads its imports and
+// compiles it.
+func (v *Validator) useSchema(schema *XSDSchema, version float64) error {
+	// Record the namespace bindings of the schema document so that type
+	// references can be resolved.
+	for _, attr := range schema.Attrs {
+		if attr.Name.Space == xmlns {
+			v.namespaces[attr.Name.Local] = attr.Value
+		} else if attr.Name.Space == "" && attr.Name.Local == xmlns {
+			v.namespaces[""] = attr.Value
+		}
+	}
+
+	v.schema = schema
+	v.defaultNS = schema.TargetNS
+	v.loaded[schema.TargetNS] = v
+	for i := range schema.Attributes {
+		schema.Attributes[i].schema = v
+	}
+	if err := v.loadImports(version); err != nil {
+		return err
+	}
+	if err := v.compileSchema(); err != nil {
+		return fmt.Errorf("failed to compile XSD: %v", err)
+	}
+	return nil
+}
+
+// parseSchema decodes a schema document. Conditional inclusion
+// (vc:minVersion/vc:maxVersion) is applied while decoding, so excluded
+// components never reach the schema model.
+func (v *Validator) parseSchema(r io.Reader, version float64) (*XSDSchema, error) {
+	filter := newVersionFilter(xml.NewDecoder(r), version)
 	schema := &XSDSchema{}
-	decoder := xml.NewDecoder(xsdFile)
-	if err := decoder.Decode(schema); err != nil {
+	if err := xml.NewTokenDecoder(filter).Decode(schema); err != nil {
 		return nil, fmt.Errorf("failed to parse XSD: %v", err)
 	}
-
-	return &Validator{
-		schema:     schema,
-		patterns:   NewPatternCache(),
-		namespaces: make(map[string]string),
-		defaultNS:  schema.TargetNS,
-	}, nil
+	if v.version == XSDVersion10 && len(filter.constructs) > 0 {
+		return nil, fmt.Errorf("failed to compile XSD: xs:%s is an XSD 1.1 construct and cannot be used with XSD %s",
+			filter.constructs[0], v.version)
+	}
+	return schema, nil
 }
 
 // Validate checks an XML file against the XSD schema and returns a ValidationResult.
@@ -52,36 +122,104 @@ func (v *Validator) Validate(xmlFile io.Reader) (*ValidationResult, error) {
 	result := &ValidationResult{
 		Valid:    true,
 		Filename: xmlNode.Name,
-		Errors:   v.validateElement(xmlNode, *rootXsd),
+		Details:  v.validateElement(xmlNode, *rootXsd),
+	}
+	for _, e := range result.Details {
+		result.Errors = append(result.Errors, e.Message)
 	}
 
 	// If any validation errors are found, mark the XML as invalid.
 	if len(result.Errors) > 0 {
 		result.Valid = false
 	}
+	if v.defaults || v.infoset {
+		result.Document = xmlNode
+	}
 
 	return result, nil
 }
 
 // validateElement performs recursive validation of an XML element against the
 // schema definition.
-func (v *Validator) validateElement(xmlNode *XMLNode, xsdElem XSDElement) []string {
-	var errors []string
+func (v *Validator) validateElement(xmlNode *XMLNode, xsdElem XSDElement) []ValidationError {
+	state, errors := v.startElement(xmlNode, xsdElem)
+	if state == nil {
+		return errors
+	}
+
+	// Validate child elements based on complex type constraints.
+	for _, child := range xmlNode.Children {
+		decls, childErrors := v.childDeclarations(state, child)
+		errors = append(errors, childErrors...)
+		for _, decl := range decls {
+			errors = append(errors, v.validateElement(child, decl)...)
+		}
+	}
+	errors = append(errors, v.endElement(xmlNode, state)...)
+
+	// Fill in the values the schema supplies for this element.
+	if v.defaults {
+		v.applyDefaults(xmlNode, &state.decl)
+	}
+
+	// Record the outcome once the children have been assessed.
+	if v.infoset {
+		xmlNode.Info = v.elementInfo(xmlNode, &state.decl, state.contentErr, len(errors) == 0)
+	}
+
+	return errors
+}
+
+// elementState is the progress of validating an element against a
+// declaration: the declaration, and the children seen so far by the content
+// model of its type. Validation starts with startElement, passes every child
+// element to childDeclarations and ends with endElement.
+type elementState struct {
+	decl XSDElement
+	// expected holds the particles of the sequence by the expanded name of
+	// the elements they match, and counts the occurrences of each name.
+	expected map[xml.Name]XSDElement
+	counts   map[xml.Name]int
+	// choices holds the nested choice groups, outermost first.
+	choices []*choiceState
+	// contentErr is the outcome of validating the text content.
+	contentErr error
+}
+
+// choiceState counts the elements matching the alternatives of a choice
+// group.
+type choiceState struct {
+	choice *XSDChoice
+	count  int
+}
+
+// startElement begins the validation of an element against a declaration
+// once its start tag has been read: it resolves element references, checks
+// the name and the attributes of the element and prepares the content model
+// of its type. It returns a nil state if the element cannot be assessed
+// further.
+func (v *Validator) startElement(xmlNode *XMLNode, xsdElem XSDElement) (*elementState, []ValidationError) {
+	var errors []ValidationError
 
 	// If the element references another definition, resolve it first.
 	if xsdElem.Ref != "" {
 		refElement, err := v.resolveElementRef(xsdElem.Ref)
 		if err != nil {
-			return append(errors, err.Error())
+			return nil, append(errors, nodeError(xmlNode.Path, xmlNode.Start, "src-resolve", "element "+xsdElem.Ref, "", "", "%v", err))
 		}
-		return v.validateElement(xmlNode, *refElement)
+		// The occurrence constraints belong to the reference.
+		resolved := *refElement
+		resolved.MinOccurs, resolved.MaxOccurs = xsdElem.MinOccurs, xsdElem.MaxOccurs
+		return v.startElement(xmlNode, resolved)
 	}
 
 	// Validate the element name and namespace.
 	if !v.validateElementNameAndNS(xmlNode, xsdElem) {
-		errors = append(errors, fmt.Sprintf("element name or namespace mismatch: expected '{%s}%s', got '{%s}%s'",
-			xsdElem.Namespace, xsdElem.Name, xmlNode.Namespace, xmlNode.Name))
-		return errors
+		expected := fmt.Sprintf("{%s}%s", xsdElem.targetNamespace, xsdElem.Name)
+		actual := fmt.Sprintf("{%s}%s", xmlNode.Namespace, xmlNode.Name)
+		errors = append(errors, nodeError(xmlNode.Path, xmlNode.Start, "cvc-complex-type.2.4.a", "element "+xsdElem.Name, expected, actual,
+			"element name or namespace mismatch: expected '%s', got '%s'", expected, actual))
+		return nil, errors
 	}
 
 	// If the element has a referenced complex type, retrieve it.
@@ -91,208 +229,253 @@ func (v *Validator) validateElement(xmlNode *XMLNode, xsdElem XSDElement) []stri
 		}
 	}
 
-	// Validate attributes of the element.
-	if xsdElem.ComplexType != nil {
-		errors = append(errors, v.validateAttributes(xmlNode.Attributes, xsdElem.ComplexType.Attributes)...)
-	}
-
-	// Validate text content inside the element.
-	if xmlNode.Content != "" {
-		if err := v.validateElementContent(xmlNode.Content, &xsdElem); err != nil {
-			errors = append(errors, fmt.Sprintf("invalid content in element '%s': %v", xmlNode.Name, err))
-		}
-	}
+	state := &elementState{decl: xsdElem}
+	if ct := xsdElem.ComplexType; ct != nil {
+		// Validate attributes of the element.
+		errors = append(errors, v.validateAttributes(xmlNode, ct.Attributes)...)
 
-	// Validate child elements based on complex type constraints.
-	if xsdElem.ComplexType != nil {
-		if xsdElem.ComplexType.Sequence != nil {
-			errors = append(errors, v.validateSequence(xmlNode.Children, xsdElem.ComplexType.Sequence)...)
+		if ct.Sequence != nil {
+			state.expected = make(map[xml.Name]XSDElement)
+			state.counts = make(map[xml.Name]int)
+			for _, childDef := range ct.Sequence.Elements {
+				state.expected[v.particleName(childDef)] = childDef
+			}
 		}
-		if xsdElem.ComplexType.Choice != nil {
-			errors = append(errors, v.validateChoice(xmlNode.Children, xsdElem.ComplexType.Choice)...)
+		for choice := ct.Choice; choice != nil; choice = choice.Choice {
+			state.choices = append(state.choices, &choiceState{choice: choice})
 		}
 	}
-
-	return errors
+	return state, errors
 }
 
-// validateChoice checks whether the child elements satisfy an XSD <choice> constraint.
-func (v *Validator) validateChoice(children []*XMLNode, choice *XSDChoice) []string {
-	var errors []string
-
-	// Parse MinOccurs and MaxOccurs values for constraints.
-	minOccurs := 1
-	if choice.MinOccurs != "" {
-		if val, err := strconv.Atoi(choice.MinOccurs); err == nil {
-			minOccurs = val
+// childDeclarations matches a child element against the content model of its
+// parent and returns the declarations the child must be validated against:
+// one for the sequence and one for every choice group it is an alternative
+// of.
+func (v *Validator) childDeclarations(state *elementState, child *XMLNode) ([]XSDElement, []ValidationError) {
+	var decls []XSDElement
+	var errors []ValidationError
+
+	if state.expected != nil {
+		name := xml.Name{Space: child.Namespace, Local: child.Name}
+		childDef, ok := state.expected[name]
+		if !ok {
+			// A particle with the same local name in another namespace is
+			// reported as a namespace mismatch when the child is validated.
+			for _, particle := range state.decl.ComplexType.Sequence.Elements {
+				if expected := v.particleName(particle); expected.Local == child.Name {
+					name, childDef, ok = expected, particle, true
+					break
+				}
+			}
 		}
-	}
-	maxOccurs := 1
-	if choice.MaxOccurs != "" {
-		if choice.MaxOccurs == "unbounded" {
-			maxOccurs = math.MaxInt32
-		} else if val, err := strconv.Atoi(choice.MaxOccurs); err == nil {
-			maxOccurs = val
+		if ok {
+			state.counts[name]++
+			decls = append(decls, childDef)
+		} else {
+			errors = append(errors, nodeError(child.Path, child.Start, "cvc-complex-type.2.4.a", "sequence", "", child.Name,
+				"unexpected element '%s'", child.Name))
 		}
 	}
 
-	// Recursively validate nested choice elements.
-	if choice.Choice != nil {
-		errors = append(errors, v.validateChoice(children, choice.Choice)...)
-	}
-
-	// Check which elements from the choice group are present.
-	validChoices := 0
-	if choice.Elements != nil {
-		for _, child := range children {
-			found := false
-			for _, choiceElem := range choice.Elements {
-				var elemToValidate XSDElement
-				if choiceElem.Ref != "" {
-					refElement, err := v.resolveElementRef(choiceElem.Ref)
-					if err != nil {
-						errors = append(errors, err.Error())
-						continue
-					}
-					elemToValidate = *refElement
-				} else {
-					elemToValidate = choiceElem
+	for _, cs := range state.choices {
+		if cs.choice.Elements == nil {
+			continue
+		}
+		found := false
+		for _, choiceElem := range cs.choice.Elements {
+			var elemToValidate XSDElement
+			if choiceElem.Ref != "" {
+				refElement, err := v.resolveElementRef(choiceElem.Ref)
+				if err != nil {
+					errors = append(errors, nodeError(child.Path, child.Start, "src-resolve", "element "+choiceElem.Ref, "", "", "%v", err))
+					continue
 				}
+				elemToValidate = *refElement
+				elemToValidate.MinOccurs, elemToValidate.MaxOccurs = choiceElem.MinOccurs, choiceElem.MaxOccurs
+			} else {
+				elemToValidate = choiceElem
+			}
 
-				if v.validateElementNameAndNS(child, elemToValidate) {
-					found = true
-					validChoices++
-					errors = append(errors, v.validateElement(child, elemToValidate)...)
-					break
-				}
+			if v.validateElementNameAndNS(child, elemToValidate) {
+				found = true
+				cs.count++
+				decls = append(decls, elemToValidate)
+				break
 			}
-			if !found {
-				errors = append(errors, fmt.Sprintf("element '%s' is not a valid choice", child.Name))
+		}
+		if !found {
+			alternatives := make([]string, 0, len(cs.choice.Elements))
+			for _, choiceElem := range cs.choice.Elements {
+				name := v.particleName(choiceElem)
+				alternatives = append(alternatives, fmt.Sprintf("{%s}%s", name.Space, name.Local))
 			}
+			expected := strings.Join(alternatives, ", ")
+			errors = append(errors, nodeError(child.Path, child.Start, "cvc-complex-type.2.4.a", "choice", expected,
+				fmt.Sprintf("{%s}%s", child.Namespace, child.Name),
+				"element '{%s}%s' is not a valid choice, expected one of: %s", child.Namespace, child.Name, expected))
 		}
 	}
-
-	// Validate occurrence constraints for the choice group.
-	if validChoices < minOccurs {
-		errors = append(errors, fmt.Sprintf("choice group occurs %d times, minimum required is %d",
-			validChoices, minOccurs))
-	}
-	if validChoices > maxOccurs {
-		errors = append(errors, fmt.Sprintf("choice group occurs %d times, maximum allowed is %d",
-			validChoices, maxOccurs))
-	}
-
-	return errors
+	return decls, errors
 }
 
-// validateSequence checks whether the child elements satisfy an XSD <sequence> constraint.
-func (v *Validator) validateSequence(children []*XMLNode, sequence *XSDSequence) []string {
-	var errors []string
-	expectedChildren := make(map[string]XSDElement)
-	counts := make(map[string]int)
+// endElement completes the validation of an element once its end tag has
+// been read: it checks the text content and the occurrence constraints of
+// the content model.
+func (v *Validator) endElement(xmlNode *XMLNode, state *elementState) []ValidationError {
+	var errors []ValidationError
+	xsdElem := &state.decl
 
-	for _, childDef := range sequence.Elements {
-		expectedChildren[childDef.Name] = childDef
-	}
-
-	for _, child := range children {
-		if childDef, ok := expectedChildren[child.Name]; ok {
-			counts[child.Name]++
-			errors = append(errors, v.validateElement(child, childDef)...)
-		} else {
-			errors = append(errors, fmt.Sprintf("unexpected element '%s'", child.Name))
-		}
+	// Validate text content inside the element.
+	state.contentErr = v.validateElementContent(xmlNode.RawContent, xsdElem, xmlNode.Namespaces)
+	if state.contentErr != nil {
+		errors = append(errors, valueError(xmlNode.Path, xmlNode.Start, "cvc-type.3.1.3", "element "+xsdElem.Name,
+			fmt.Sprintf("invalid content in element '%s': ", xmlNode.Name), state.contentErr))
 	}
 
 	// Validate sequence occurrence constraints
-	for _, childDef := range sequence.Elements {
-		minOccurs := 1
-		if childDef.MinOccurs != "" {
-			if val, err := strconv.Atoi(childDef.MinOccurs); err == nil {
-				minOccurs = val
+	if state.expected != nil {
+		for _, childDef := range xsdElem.ComplexType.Sequence.Elements {
+			name := v.particleName(childDef)
+			minOccurs, maxOccurs := occurrences(childDef.MinOccurs, childDef.MaxOccurs)
+			count := state.counts[name]
+			if count < minOccurs {
+				errors = append(errors, nodeError(xmlNode.Path, xmlNode.Start, "cvc-complex-type.2.4.b", "element "+name.Local,
+					fmt.Sprintf("at least %d", minOccurs), strconv.Itoa(count),
+					"element '%s' occurs %d times, minimum required is %d", name.Local, count, minOccurs))
 			}
-		}
-
-		maxOccurs := 1
-		if childDef.MaxOccurs != "" {
-			if childDef.MaxOccurs == "unbounded" {
-				maxOccurs = math.MaxInt32
-			} else if val, err := strconv.Atoi(childDef.MaxOccurs); err == nil {
-				maxOccurs = val
+			if count > maxOccurs {
+				errors = append(errors, nodeError(xmlNode.Path, xmlNode.Start, "cvc-complex-type.2.4.d", "element "+name.Local,
+					fmt.Sprintf("at most %d", maxOccurs), strconv.Itoa(count),
+					"element '%s' occurs %d times, maximum allowed is %d", name.Local, count, maxOccurs))
 			}
 		}
+	}
 
-		count := counts[childDef.Name]
-		if count < minOccurs {
-			errors = append(errors, fmt.Sprintf("element '%s' occurs %d times, minimum required is %d",
-				childDef.Name, count, minOccurs))
+	// Validate occurrence constraints for the choice groups.
+	for _, cs := range state.choices {
+		minOccurs, maxOccurs := occurrences(cs.choice.MinOccurs, cs.choice.MaxOccurs)
+		if cs.count < minOccurs {
+			errors = append(errors, nodeError(xmlNode.Path, xmlNode.Start, "cvc-complex-type.2.4.b", "choice",
+				fmt.Sprintf("at least %d", minOccurs), strconv.Itoa(cs.count),
+				"choice group occurs %d times, minimum required is %d", cs.count, minOccurs))
 		}
-		if count > maxOccurs {
-			errors = append(errors, fmt.Sprintf("element '%s' occurs %d times, maximum allowed is %d",
-				childDef.Name, count, maxOccurs))
+		if cs.count > maxOccurs {
+			errors = append(errors, nodeError(xmlNode.Path, xmlNode.Start, "cvc-complex-type.2.4.d", "choice",
+				fmt.Sprintf("at most %d", maxOccurs), strconv.Itoa(cs.count),
+				"choice group occurs %d times, maximum allowed is %d", cs.count, maxOccurs))
 		}
 	}
 
 	return errors
 }
 
-func (v *Validator) validateAttributes(nodeAttrs map[string]string, schemaAttrs []XSDAttribute) []string {
-	errors := make([]string, 0, len(nodeAttrs)+len(schemaAttrs))
+// occurrences parses the minOccurs and maxOccurs attributes of a particle,
+// which both default to 1.
+func occurrences(minOccursAttr, maxOccursAttr string) (int, int) {
+	minOccurs := 1
+	if minOccursAttr != "" {
+		if val, err := strconv.Atoi(minOccursAttr); err == nil {
+			minOccurs = val
+		}
+	}
+	maxOccurs := 1
+	if maxOccursAttr != "" {
+		if maxOccursAttr == "unbounded" {
+			maxOccurs = math.MaxInt32
+		} else if val, err := strconv.Atoi(maxOccursAttr); err == nil {
+			maxOccurs = val
+		}
+	}
+	return minOccurs, maxOccurs
+}
+
+// validateAttributes checks the attributes of an element against the attribute
+// uses of its type. Attributes are matched by expanded name; the schema
+// instance attributes are allowed on every element.
+func (v *Validator) validateAttributes(xmlNode *XMLNode, schemaAttrs []XSDAttribute) []ValidationError {
+	errors := make([]ValidationError, 0, len(xmlNode.Attributes)+len(schemaAttrs))
 
-	// Create a map of required attributes from schema
+	// Index the declared attributes by the key they have in the node and
+	// collect the required ones.
+	declared := make(map[string]XSDAttribute, len(schemaAttrs))
 	requiredAttrs := make(map[string]XSDAttribute)
 	for _, attr := range schemaAttrs {
-		if attr.Use == "required" {
-			requiredAttrs[attr.Name] = attr
+		decl, name, err := v.resolveAttribute(attr)
+		if err != nil {
+			errors = append(errors, nodeError(xmlNode.Path, xmlNode.Start, "src-resolve", "attribute "+attr.Ref, "", "", "%v", err))
+			continue
+		}
+		declared[name] = decl
+		if decl.Use == "required" {
+			requiredAttrs[name] = decl
 		}
 	}
 
 	// Check all attributes in node
-	for name, value := range nodeAttrs {
-		// Find corresponding schema attribute
-		var found bool
-		for _, schemaAttr := range schemaAttrs {
-			if schemaAttr.Name == name {
-				found = true
-				delete(requiredAttrs, name) // Remove from required map if found
-
-				// Validate attribute value (basic type checking)
-				if err := v.validateAttributeValue(value, schemaAttr); err != nil {
-					errors = append(errors, fmt.Sprintf("attribute '%s': %s", name, err))
-				}
-				break
+	for name, value := range xmlNode.Attributes {
+		schemaAttr, found := declared[name]
+		if !found {
+			if !isSchemaInstanceAttribute(name) {
+				errors = append(errors, nodeError(attributePath(xmlNode, name), attributePosition(xmlNode, name), "cvc-complex-type.3.2.2", "attribute "+name,
+					"", value, "unexpected attribute '%s'", name))
 			}
+			continue
 		}
+		delete(requiredAttrs, name)
 
-		if !found {
-			errors = append(errors, fmt.Sprintf("unexpected attribute '%s'", name))
+		if err := v.validateAttributeValue(value, schemaAttr, xmlNode.Namespaces); err != nil {
+			errors = append(errors, valueError(attributePath(xmlNode, name), attributePosition(xmlNode, name), "cvc-attribute.3", "attribute "+name,
+				fmt.Sprintf("attribute '%s': ", name), err))
 		}
 	}
 
 	// Check if any required attributes are missing
 	for name := range requiredAttrs {
-		errors = append(errors, fmt.Sprintf("missing required attribute '%s'", name))
+		errors = append(errors, nodeError(xmlNode.Path, xmlNode.Start, "cvc-complex-type.4", "attribute "+name, "", "",
+			"missing required attribute '%s'", name))
 	}
 
 	return errors
 }
 
-// validateAttributeValue calls the appropriate type validation
-func (v *Validator) validateAttributeValue(value string, attr XSDAttribute) error {
+// validateAttributeValue calls the appropriate type validation. Global
+// declarations of imported schemas are validated by the validator of their
+// schema document.
+func (v *Validator) validateAttributeValue(value string, attr XSDAttribute, scope map[string]string) error {
+	if attr.schema != nil && attr.schema != v {
+		return attr.schema.validateAttributeValue(value, attr, scope)
+	}
 	if attr.Type != "" {
-		return v.validateType(value, attr.Type, nil)
-	} else if attr.SimpleType != nil && attr.SimpleType.Restriction != nil {
-		return v.validateType(value, attr.SimpleType.Restriction.Base, attr.SimpleType.Restriction)
+		if err := v.validateType(value, attr.Type, nil, scope); err != nil {
+			return err
+		}
+	} else if attr.SimpleType != nil {
+		if err := v.validateSimpleType(value, attr.SimpleType, scope); err != nil {
+			return err
+		}
 	}
-	return nil
+	return constraintCode(v.checkFixed(value, attr.Fixed, attr.Type, attr.SimpleType, scope), "cvc-attribute.4")
 }
 
-// validateElementContent validates element content
-func (v *Validator) validateElementContent(content string, element *XSDElement) error {
+// validateElementContent validates the raw text content of an element whose
+// type is a simple type. Content of complex types is not checked here.
+func (v *Validator) validateElementContent(content string, element *XSDElement, scope map[string]string) error {
+	// An empty element takes the default or fixed value of its declaration.
+	if content == "" {
+		content = element.valueConstraint()
+	}
+
 	if element.SimpleType != nil {
-		return v.validateType(content, element.SimpleType.Restriction.Base, element.SimpleType.Restriction)
-	} else if element.Type != "" {
-		return v.validateType(content, element.Type, nil)
+		if err := v.validateSimpleType(content, element.SimpleType, scope); err != nil {
+			return err
+		}
+	} else if element.Type != "" && v.isSimpleType(element.Type) {
+		if err := v.validateType(content, element.Type, nil, scope); err != nil {
+			return err
+		}
+	} else {
+		return nil
 	}
-	return nil
+	return constraintCode(v.checkFixed(content, element.Fixed, element.Type, element.SimpleType, scope), "cvc-elt.5.2.2.2.2")
 }
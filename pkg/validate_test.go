@@ -2,7 +2,16 @@ package pkg
 
 import (
 	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/big"
+	"runtime"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestXMLValidator(t *testing.T) {
@@ -37,7 +46,7 @@ func TestXMLValidator(t *testing.T) {
 			xmlInput: `<?xml version="1.0" encoding="UTF-8"?><priority>urgent</priority>`,
 			xsdInput: `<?xml version="1.0" encoding="UTF-8"?><xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="priority"><xs:simpleType><xs:restriction base="xs:string"><xs:enumeration value="high"/><xs:enumeration value="medium"/><xs:enumeration value="low"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
 			valid:    false,
-			errors:   []string{"invalid content in element 'priority': value must be one of the enumerated values"},
+			errors:   []string{"invalid content in element 'priority': value 'urgent' must be one of: high, medium, low"},
 		},
 		{
 			name:     "Pattern Restriction - Valid",
@@ -66,40 +75,1572 @@ func TestXMLValidator(t *testing.T) {
 			valid:    false,
 			errors:   []string{"element 'author' occurs 0 times, minimum required is 1"},
 		},
+		{
+			name:     "Built-in Type - Token With Consecutive Spaces",
+			xmlInput: `<code>a  b</code>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="code" type="xs:token"/></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Built-in Type - Language Tag",
+			xmlInput: `<lang>en-toolongsubtag</lang>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="lang" type="xs:language"/></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'lang': invalid language value: en-toolongsubtag"},
+		},
+		{
+			name:     "Built-in Type - User Type Shadows Unprefixed Name",
+			xmlInput: `<size>b</size>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="token"><xs:restriction base="xs:string"><xs:enumeration value="a"/></xs:restriction></xs:simpleType><xs:element name="size" type="token"/></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'size': value 'b' must be one of: a"},
+		},
+		{
+			name:     "Prefixed Simple Type Reference",
+			xmlInput: `<a:code xmlns:a="urn:a">x</a:code>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:a="urn:a" targetNamespace="urn:a"><xs:simpleType name="S"><xs:restriction base="xs:int"/></xs:simpleType><xs:element name="code" type="a:S"/></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'code': invalid int value: x"},
+		},
+		{
+			name:     "Prefixed Complex Type Reference",
+			xmlInput: `<a:order xmlns:a="urn:a"><bad/></a:order>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:a="urn:a" targetNamespace="urn:a"><xs:complexType name="T"><xs:sequence><xs:element name="item" minOccurs="0"/></xs:sequence></xs:complexType><xs:element name="order" type="a:T"/></xs:schema>`,
+			valid:    false,
+			errors:   []string{"unexpected element 'bad'"},
+		},
+		{
+			name:     "Imported Type Reference Without Resolver",
+			xmlInput: `<a:code xmlns:a="urn:a" unit="m"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:a="urn:a" xmlns:b="urn:b" targetNamespace="urn:a"><xs:import namespace="urn:b"/><xs:element name="code"><xs:complexType><xs:attribute name="unit" type="b:Unit"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"attribute 'unit': unsupported type: b:Unit"},
+		},
+		{
+			name:     "Built-in Type - NCName With Colon",
+			xmlInput: `<id>a:b</id>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="id" type="xs:NCName"/></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'id': invalid NCName value: a:b"},
+		},
+		{
+			name:     "Built-in Type - UnsignedByte Out Of Range",
+			xmlInput: `<level>256</level>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="level" type="xs:unsignedByte"/></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'level': invalid unsignedByte value: 256"},
+		},
+		{
+			name:     "Built-in Type - UnsignedLong Above Int64",
+			xmlInput: `<counter>18446744073709551615</counter>`,
+			xsdInput: `<xsd:schema xmlns:xsd="http://www.w3.org/2001/XMLSchema"><xsd:element name="counter" type="xsd:unsignedLong"/></xsd:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Built-in Type - NMTOKENS List",
+			xmlInput: `<tags>alpha beta-1 gamma.2</tags>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="tags" type="xs:NMTOKENS"/></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Built-in Type - gMonthDay",
+			xmlInput: `<birthday>--02-29</birthday>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="birthday" type="xs:gMonthDay"/></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Built-in Type - gMonthDay Out Of Range",
+			xmlInput: `<birthday>--02-30</birthday>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="birthday" type="xs:gMonthDay"/></xs:schema>`,
+			valid:    false,
+		},
+		{
+			name:     "Built-in Type - XSD 1.1 Type Under XSD 1.0",
+			xmlInput: `<at>2024-01-01T10:00:00Z</at>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="at" type="xs:dateTimeStamp"/></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'at': type dateTimeStamp requires XSD 1.1"},
+		},
+		{
+			name:     "Restriction Of Short Applies Range Facets",
+			xmlInput: `<floor>-3</floor>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="floor"><xs:simpleType><xs:restriction base="xs:short"><xs:minInclusive value="0"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'floor': value must be >= 0, got -3"},
+		},
+		{
+			name:     "Arbitrary Precision - 30 Digit Integer",
+			xmlInput: `<serial>123456789012345678901234567890</serial>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="serial" type="xs:integer"/></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Arbitrary Precision - Decimal With Exponent",
+			xmlInput: `<amount>1e5</amount>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="amount" type="xs:decimal"/></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'amount': invalid decimal value: 1e5"},
+		},
+		{
+			name:     "Arbitrary Precision - Exact Max Inclusive",
+			xmlInput: `<ratio>0.30000000000000001</ratio>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="ratio"><xs:simpleType><xs:restriction base="xs:decimal"><xs:maxInclusive value="0.3"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'ratio': value must be <= 0.3, got 0.30000000000000001"},
+		},
+		{
+			name:     "Arbitrary Precision - Large Integer Range",
+			xmlInput: `<id>99999999999999999999</id>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="id"><xs:simpleType><xs:restriction base="xs:integer"><xs:minExclusive value="99999999999999999998"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Fraction Digits - Too Many",
+			xmlInput: `<price>9.999</price>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="price"><xs:simpleType><xs:restriction base="xs:decimal"><xs:fractionDigits value="2"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'price': value must have at most 2 fraction digits, got 3"},
+		},
+		{
+			name:     "Fraction Digits - Trailing Zeros Ignored",
+			xmlInput: `<price>9.9900</price>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="price"><xs:simpleType><xs:restriction base="xs:decimal"><xs:fractionDigits value="2"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Total Digits - Leading Zeros Ignored",
+			xmlInput: `<code>000123.40</code>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="code"><xs:simpleType><xs:restriction base="xs:decimal"><xs:totalDigits value="4"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Total Digits - Too Many On Integer Type",
+			xmlInput: `<code>12345</code>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="code"><xs:simpleType><xs:restriction base="xs:int"><xs:totalDigits value="4"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'code': value must have at most 4 total digits, got 5"},
+		},
+		{
+			name:     "White Space - Token Collapses Before Pattern",
+			xmlInput: "<code>\n  AB\t  12  \n</code>",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="code"><xs:simpleType><xs:restriction base="xs:token"><xs:pattern value="[A-Z]{2} [0-9]{2}"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "White Space - String Preserves Surrounding Spaces",
+			xmlInput: `<code> AB </code>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="code"><xs:simpleType><xs:restriction base="xs:string"><xs:maxLength value="2"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'code': length must be at most 2, got 4"},
+		},
+		{
+			name:     "White Space - Collapse Facet On String Restriction",
+			xmlInput: `<code> AB </code>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="code"><xs:simpleType><xs:restriction base="xs:string"><xs:whiteSpace value="collapse"/><xs:maxLength value="2"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "White Space - Numeric Attribute Collapsed",
+			xmlInput: `<item qty=" 42 "/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="item"><xs:complexType><xs:attribute name="qty" type="xs:int"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "White Space - Empty Content Checked Against Simple Type",
+			xmlInput: `<age></age>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="age" type="xs:int"/></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'age': invalid int value: "},
+		},
+		{
+			name:     "Pattern Restriction - Unanchored Match Rejected",
+			xmlInput: `<code>xxAByy</code>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="code"><xs:simpleType><xs:restriction base="xs:string"><xs:pattern value="[A-Z]{2}"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'code': value does not match pattern: [A-Z]{2}"},
+		},
+		{
+			name:     "Pattern Restriction - Patterns In One Step Are Alternatives",
+			xmlInput: `<code>12</code>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="code"><xs:simpleType><xs:restriction base="xs:string"><xs:pattern value="[A-Z]{2}"/><xs:pattern value="\d{2}"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "List Type - Valid Items",
+			xmlInput: "<sizes>\n 1 2\t3 </sizes>",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="sizeList"><xs:list itemType="xs:int"/></xs:simpleType><xs:element name="sizes" type="sizeList"/></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "List Type - Invalid Item",
+			xmlInput: `<sizes>1 two 3</sizes>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="sizeList"><xs:list itemType="xs:int"/></xs:simpleType><xs:element name="sizes" type="sizeList"/></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'sizes': invalid list item 'two': invalid int value: two"},
+		},
+		{
+			name:     "List Type - Anonymous Item Type",
+			xmlInput: `<colors>red blue purple</colors>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="colors"><xs:simpleType><xs:list><xs:simpleType><xs:restriction base="xs:token"><xs:enumeration value="red"/><xs:enumeration value="blue"/></xs:restriction></xs:simpleType></xs:list></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'colors': invalid list item 'purple': value 'purple' must be one of: red, blue"},
+		},
+		{
+			name:     "List Type - Length Counts Items",
+			xmlInput: `<point>10 20 30</point>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="decimalList"><xs:list itemType="xs:decimal"/></xs:simpleType><xs:element name="point"><xs:simpleType><xs:restriction base="decimalList"><xs:length value="2"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'point': length must be exactly 2, got 3"},
+		},
+		{
+			name:     "List Type - Pattern And Enumeration On Whole List",
+			xmlInput: `<pair>  1   2 </pair>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="intList"><xs:list itemType="xs:int"/></xs:simpleType><xs:element name="pair"><xs:simpleType><xs:restriction base="intList"><xs:pattern value="\d \d"/><xs:enumeration value="1 2"/><xs:enumeration value="3 4"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "List Type - Built-in NMTOKENS Max Length",
+			xmlInput: `<tags>a b c</tags>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="tags"><xs:simpleType><xs:restriction base="xs:NMTOKENS"><xs:maxLength value="2"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'tags': length must be at most 2, got 3"},
+		},
+		{
+			name:     "Union Type - Member Types Attribute",
+			xmlInput: `<size>large</size>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="sizeName"><xs:restriction base="xs:token"><xs:enumeration value="small"/><xs:enumeration value="large"/></xs:restriction></xs:simpleType><xs:simpleType name="sizeType"><xs:union memberTypes="xs:positiveInteger  sizeName"/></xs:simpleType><xs:element name="size" type="sizeType"/></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Union Type - Anonymous Members",
+			xmlInput: `<size>huge</size>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="size"><xs:simpleType><xs:union memberTypes="xs:int"><xs:simpleType><xs:restriction base="xs:token"><xs:enumeration value="small"/></xs:restriction></xs:simpleType></xs:union></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'size': value 'huge' does not match any member type of the union (xs:int, #2)"},
+		},
+		{
+			name:     "Union Type - Restricted By Enumeration",
+			xmlInput: `<size>7</size>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="sizeType"><xs:union memberTypes="xs:int xs:token"/></xs:simpleType><xs:element name="size"><xs:simpleType><xs:restriction base="sizeType"><xs:enumeration value="1"/><xs:enumeration value="auto"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'size': value '7' must be one of: 1, auto"},
+		},
+		{
+			name:     "Union Type - Restricted By Pattern",
+			xmlInput: `<size>auto</size>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="sizeType"><xs:union memberTypes="xs:int xs:token"/></xs:simpleType><xs:element name="size"><xs:simpleType><xs:restriction base="sizeType"><xs:pattern value="\d+|auto"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Facet Inheritance - Range Of Derived Decimal Type",
+			xmlInput: `<discount>75</discount>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="amountType"><xs:restriction base="xs:decimal"><xs:fractionDigits value="2"/></xs:restriction></xs:simpleType><xs:simpleType name="percentType"><xs:restriction base="amountType"><xs:maxInclusive value="100"/></xs:restriction></xs:simpleType><xs:element name="discount"><xs:simpleType><xs:restriction base="percentType"><xs:maxInclusive value="50"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'discount': value must be <= 50, got 75"},
+		},
+		{
+			name:     "Facet Inheritance - Base Facets Still Apply",
+			xmlInput: `<discount>12.345</discount>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="amountType"><xs:restriction base="xs:decimal"><xs:fractionDigits value="2"/></xs:restriction></xs:simpleType><xs:simpleType name="percentType"><xs:restriction base="amountType"><xs:maxInclusive value="100"/></xs:restriction></xs:simpleType><xs:element name="discount" type="percentType"/></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'discount': value must have at most 2 fraction digits, got 3"},
+		},
+		{
+			name:     "Date Time - Timezones And Fractional Seconds",
+			xmlInput: `<times><at>2024-03-01T10:15:30.125Z</at><at>2024-03-01T10:15:30+02:00</at><at>2024-03-01T24:00:00</at></times>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="times"><xs:complexType><xs:sequence><xs:element name="at" type="xs:dateTime" maxOccurs="unbounded"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Date Time - Negative And Five Digit Years",
+			xmlInput: `<dates><on>-0044-03-15</on><on>12024-01-01Z</on></dates>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="dates"><xs:complexType><xs:sequence><xs:element name="on" type="xs:date" maxOccurs="unbounded"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Date Time - Invalid Day Of Month",
+			xmlInput: `<on>2023-02-29</on>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="on" type="xs:date"/></xs:schema>`,
+			valid:    false,
+		},
+		{
+			name:     "Date Time - Timezone Out Of Range",
+			xmlInput: `<at>10:00:00+14:30</at>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="at" type="xs:time"/></xs:schema>`,
+			valid:    false,
+		},
+		{
+			name:     "Date Time - Max Inclusive Compares Across Timezones",
+			xmlInput: `<on>2024-12-31T23:00:00-02:00</on>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="on"><xs:simpleType><xs:restriction base="xs:dateTime"><xs:maxInclusive value="2024-12-31T23:59:59Z"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+		},
+		{
+			name:     "Date Time - Min Inclusive On Date",
+			xmlInput: `<on>2024-01-02</on>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="on"><xs:simpleType><xs:restriction base="xs:date"><xs:minInclusive value="2024-01-01"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Date Time - Indeterminate Comparison Fails Facet",
+			xmlInput: `<on>2024-01-01T05:00:00</on>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="on"><xs:simpleType><xs:restriction base="xs:dateTime"><xs:minInclusive value="2024-01-01T00:00:00Z"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+		},
+		{
+			name:     "Date Time - Determinate Comparison Without Timezone",
+			xmlInput: `<on>2024-01-02T05:00:00</on>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="on"><xs:simpleType><xs:restriction base="xs:dateTime"><xs:minInclusive value="2024-01-01T00:00:00Z"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Duration - Empty Designators",
+			xmlInput: `<timeouts><t>P</t></timeouts>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="timeouts"><xs:complexType><xs:sequence><xs:element name="t" type="xs:duration" maxOccurs="unbounded"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+		},
+		{
+			name:     "Duration - Time Designator Without Fields",
+			xmlInput: `<t>P1DT</t>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="t" type="xs:duration"/></xs:schema>`,
+			valid:    false,
+		},
+		{
+			name:     "Duration - Max Inclusive",
+			xmlInput: `<timeout>PT90S</timeout>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="timeout"><xs:simpleType><xs:restriction base="xs:duration"><xs:minExclusive value="PT0S"/><xs:maxInclusive value="PT1M"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+		},
+		{
+			name:     "Duration - Within Range",
+			xmlInput: `<timeout>PT45.5S</timeout>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="timeout"><xs:simpleType><xs:restriction base="xs:duration"><xs:minExclusive value="PT0S"/><xs:maxInclusive value="PT1M"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Duration - Indeterminate Comparison Fails Facet",
+			xmlInput: `<window>P30D</window>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="window"><xs:simpleType><xs:restriction base="xs:duration"><xs:maxInclusive value="P1M"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+		},
+		{
+			name:     "Enumeration - Decimal Value Space",
+			xmlInput: `<rate>1.00</rate>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="rate"><xs:simpleType><xs:restriction base="xs:decimal"><xs:enumeration value="1.0"/><xs:enumeration value="2.5"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Enumeration - Boolean Value Space",
+			xmlInput: `<enabled>1</enabled>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="enabled"><xs:simpleType><xs:restriction base="xs:boolean"><xs:enumeration value="true"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Enumeration - QName Resolved Against Namespaces",
+			xmlInput: `<code xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/">soap:Server</code>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:env="http://schemas.xmlsoap.org/soap/envelope/"><xs:element name="code"><xs:simpleType><xs:restriction base="xs:QName"><xs:enumeration value="env:Server"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Enumeration - Union Member Value Space",
+			xmlInput: `<size>01</size>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="sizeType"><xs:union memberTypes="xs:int xs:token"/></xs:simpleType><xs:element name="size"><xs:simpleType><xs:restriction base="sizeType"><xs:enumeration value="1"/><xs:enumeration value="auto"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Enumeration - List Value Space",
+			xmlInput: `<point>1.50 2</point>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="coords"><xs:list itemType="xs:decimal"/></xs:simpleType><xs:element name="point"><xs:simpleType><xs:restriction base="coords"><xs:enumeration value="1.5 2.0"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Fixed Attribute - Value Space",
+			xmlInput: `<price currency="EUR" scale="2.0">10</price>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="price"><xs:complexType><xs:attribute name="currency" type="xs:string" fixed="EUR"/><xs:attribute name="scale" type="xs:decimal" fixed="2"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Fixed Attribute - Different Value",
+			xmlInput: `<price currency="USD">10</price>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="price"><xs:complexType><xs:attribute name="currency" type="xs:string" fixed="EUR"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"attribute 'currency': value 'USD' must be equal to the fixed value 'EUR'"},
+		},
+		{
+			name:     "Fixed Element - Value Space",
+			xmlInput: `<version>01</version>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="version" type="xs:int" fixed="1"/></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "QName - Prefix Bound On Ancestor",
+			xmlInput: `<fault xmlns:env="http://www.w3.org/2003/05/soap-envelope"><code>env:Receiver</code></fault>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="fault"><xs:complexType><xs:sequence><xs:element name="code" type="xs:QName"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "QName - Unbound Prefix",
+			xmlInput: `<fault><code>env:Receiver</code></fault>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="fault"><xs:complexType><xs:sequence><xs:element name="code" type="xs:QName"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'code': invalid QName value: env:Receiver: prefix env is not bound"},
+		},
+		{
+			name:     "QName - Attribute Resolved Against Element Scope",
+			xmlInput: `<route xmlns:r="urn:routes" target="r:orders"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="route"><xs:complexType><xs:attribute name="target" type="xs:QName"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "QName - Enumeration Compares Namespaces Not Prefixes",
+			xmlInput: `<code xmlns:env="urn:other">env:Server</code>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:env="http://schemas.xmlsoap.org/soap/envelope/"><xs:element name="code"><xs:simpleType><xs:restriction base="xs:QName"><xs:enumeration value="env:Server"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+		},
+		{
+			name:     "NOTATION - Declared Notation",
+			xmlInput: `<image format="png"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:notation name="png" public="image/png"/><xs:notation name="jpeg" public="image/jpeg"/><xs:simpleType name="formatType"><xs:restriction base="xs:NOTATION"><xs:enumeration value="png"/><xs:enumeration value="jpeg"/></xs:restriction></xs:simpleType><xs:element name="image"><xs:complexType><xs:attribute name="format" type="formatType"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "NOTATION - Undeclared Notation",
+			xmlInput: `<image format="gif"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:notation name="png" public="image/png"/><xs:element name="image"><xs:complexType><xs:attribute name="format" type="xs:NOTATION"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"attribute 'format': invalid NOTATION value: gif: notation is not declared"},
+		},
+		{
+			name:     "Float - Special Values",
+			xmlInput: `<values><v>INF</v><v>-INF</v><v>NaN</v><v>-0</v><v>1.5E-3</v><v>.5e10</v></values>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="values"><xs:complexType><xs:sequence><xs:element name="v" type="xs:float" maxOccurs="unbounded"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Float - Forms Outside The Lexical Space",
+			xmlInput: `<values><v>Inf</v><v>infinity</v><v>nan</v><v>0x1p-2</v><v>1_000</v><v>+INF</v></values>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="values"><xs:complexType><xs:sequence><xs:element name="v" type="xs:double" maxOccurs="unbounded"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors: []string{
+				"invalid content in element 'v': invalid double value: Inf",
+				"invalid content in element 'v': invalid double value: infinity",
+				"invalid content in element 'v': invalid double value: nan",
+				"invalid content in element 'v': invalid double value: 0x1p-2",
+				"invalid content in element 'v': invalid double value: 1_000",
+				"invalid content in element 'v': invalid double value: +INF: +INF requires XSD 1.1",
+			},
+		},
+		{
+			name:     "Float - Rounded To 32 Bits Before Comparison",
+			xmlInput: `<v>16777217</v>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="v"><xs:simpleType><xs:restriction base="xs:float"><xs:maxInclusive value="16777216"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Double - Not Rounded To 32 Bits",
+			xmlInput: `<v>16777217</v>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="v"><xs:simpleType><xs:restriction base="xs:double"><xs:maxInclusive value="16777216"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+		},
+		{
+			name:     "Float - NaN Fails Range Facets",
+			xmlInput: `<v>NaN</v>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="v"><xs:simpleType><xs:restriction base="xs:float"><xs:minInclusive value="-INF"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'v': value must be >= -INF, got NaN"},
+		},
+		{
+			name:     "Float - Negative Zero Within Range",
+			xmlInput: `<v>-0</v>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="v"><xs:simpleType><xs:restriction base="xs:double"><xs:minInclusive value="0"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Float - NaN Enumeration",
+			xmlInput: `<v>NaN</v>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="v"><xs:simpleType><xs:restriction base="xs:double"><xs:enumeration value="NaN"/><xs:enumeration value="0"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Binary - Base64 With Whitespace",
+			xmlInput: "<cert>TWFu\n  TWE=</cert>",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="cert" type="xs:base64Binary"/></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Binary - Base64 Invalid Padding",
+			xmlInput: `<certs><cert>TWF=</cert><cert>TQ=</cert><cert>T===</cert></certs>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="certs"><xs:complexType><xs:sequence><xs:element name="cert" type="xs:base64Binary" maxOccurs="unbounded"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors: []string{
+				"invalid content in element 'cert': invalid base64Binary value: TWF=",
+				"invalid content in element 'cert': invalid base64Binary value: TQ=",
+				"invalid content in element 'cert': invalid base64Binary value: T===",
+			},
+		},
+		{
+			name:     "Binary - Hex Odd Length",
+			xmlInput: `<hash>abc</hash>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="hash" type="xs:hexBinary"/></xs:schema>`,
+			valid:    false,
+		},
+		{
+			name:     "Binary - Hex Length In Octets",
+			xmlInput: `<hash>0123456789abcdef0123456789ABCDEF</hash>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="hash"><xs:simpleType><xs:restriction base="xs:hexBinary"><xs:length value="16"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Binary - Base64 Max Length In Octets",
+			xmlInput: `<key>AAECAwQF</key>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="key"><xs:simpleType><xs:restriction base="xs:base64Binary"><xs:maxLength value="4"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"invalid content in element 'key': length must be at most 4, got 6"},
+		},
+		{
+			name:     "AnyURI - Relative References",
+			xmlInput: `<links><href>../a.xml</href><href>#frag</href><href>http://example.com:8080/a?b=c#d</href><href>urn:isbn:0451450523</href><href>//[2001:db8::1]/x</href><href>/caf%C3%A9/ménu</href></links>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="links"><xs:complexType><xs:sequence><xs:element name="href" type="xs:anyURI" maxOccurs="unbounded"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "AnyURI - Invalid References",
+			xmlInput: `<links><href>a b</href><href>100%</href><href>1a:b</href><href>a#b#c</href><href>http://host:port/</href></links>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="links"><xs:complexType><xs:sequence><xs:element name="href" type="xs:anyURI" maxOccurs="unbounded"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors: []string{
+				"invalid content in element 'href': invalid anyURI value: a b",
+				"invalid content in element 'href': invalid anyURI value: 100%",
+				"invalid content in element 'href': invalid anyURI value: 1a:b",
+				"invalid content in element 'href': invalid anyURI value: a#b#c",
+				"invalid content in element 'href': invalid anyURI value: http://host:port/",
+			},
+		},
+		{
+			name:     "Attributes - Qualified By attributeFormDefault",
+			xmlInput: `<o:order xmlns:o="urn:orders" o:id="7"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:orders" elementFormDefault="qualified" attributeFormDefault="qualified"><xs:element name="order"><xs:complexType><xs:attribute name="id" type="xs:int" use="required"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Attributes - Unqualified Attribute Where Qualified Expected",
+			xmlInput: `<o:order xmlns:o="urn:orders" id="7"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:orders" elementFormDefault="qualified"><xs:element name="order"><xs:complexType><xs:attribute name="id" type="xs:int" form="qualified" use="required"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors: []string{
+				"unexpected attribute 'id'",
+				"missing required attribute '{urn:orders}id'",
+			},
+		},
+		{
+			name:     "Attributes - Global Attribute Reference",
+			xmlInput: `<o:order xmlns:o="urn:orders" o:version="2"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:o="urn:orders" targetNamespace="urn:orders" elementFormDefault="qualified"><xs:attribute name="version" type="xs:int"/><xs:element name="order"><xs:complexType><xs:attribute ref="o:version" use="required"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Attributes - XML Namespace Reference",
+			xmlInput: `<note xml:lang="en-GB" xml:space="preserve"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="note"><xs:complexType><xs:attribute ref="xml:lang"/><xs:attribute ref="xml:space"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Attributes - Invalid xml:space Value",
+			xmlInput: `<note xml:space="keep"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="note"><xs:complexType><xs:attribute ref="xml:space"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"attribute '{http://www.w3.org/XML/1998/namespace}space': value 'keep' must be one of: default, preserve"},
+		},
+		{
+			name:     "Attributes - Schema Instance Attributes Allowed",
+			xmlInput: `<note xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="note.xsd"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="note"><xs:complexType/></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Attributes - Unknown Schema Instance Attribute",
+			xmlInput: `<note xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:color="red"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="note"><xs:complexType/></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"unexpected attribute '{http://www.w3.org/2001/XMLSchema-instance}color'"},
+		},
+		{
+			name:     "Element Form - Unqualified Locals In Target Namespace Schema",
+			xmlInput: `<o:order xmlns:o="urn:orders"><item>pen</item></o:order>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:orders"><xs:element name="order"><xs:complexType><xs:sequence><xs:element name="item" type="xs:string"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Element Form - Unqualified Local Must Not Be Qualified",
+			xmlInput: `<order xmlns="urn:orders"><item>pen</item></order>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:orders"><xs:element name="order"><xs:complexType><xs:sequence><xs:element name="item" type="xs:string"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"element name or namespace mismatch: expected '{}item', got '{urn:orders}item'"},
+		},
+		{
+			name:     "Element Form - Qualified Local Element In Other Namespace",
+			xmlInput: `<order xmlns="urn:orders"><item xmlns="urn:other">pen</item></order>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:orders" elementFormDefault="qualified"><xs:element name="order"><xs:complexType><xs:sequence><xs:element name="item" type="xs:string"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"element name or namespace mismatch: expected '{urn:orders}item', got '{urn:other}item'"},
+		},
+		{
+			name:     "Element Form - Form Attribute Overrides Default",
+			xmlInput: `<order xmlns="urn:orders"><note xmlns="">fragile</note></order>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:orders" elementFormDefault="qualified"><xs:element name="order"><xs:complexType><xs:sequence><xs:element name="note" type="xs:string" form="unqualified"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Element Form - Reference To Global Element",
+			xmlInput: `<o:order xmlns:o="urn:orders"><o:item>pen</o:item></o:order>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:o="urn:orders" targetNamespace="urn:orders"><xs:element name="item" type="xs:string"/><xs:element name="order"><xs:complexType><xs:choice><xs:element ref="o:item"/></xs:choice></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Element Form - Sequence Reference To Global Element",
+			xmlInput: `<o:order xmlns:o="urn:orders"><o:item>pen</o:item><o:item>ink</o:item><note>fragile</note></o:order>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:o="urn:orders" targetNamespace="urn:orders"><xs:element name="item" type="xs:string"/><xs:element name="order"><xs:complexType><xs:sequence><xs:element ref="o:item" maxOccurs="2"/><xs:element name="note" type="xs:string"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+		{
+			name:     "Element Form - Sequence Reference Occurrences",
+			xmlInput: `<o:order xmlns:o="urn:orders"><o:item>pen</o:item><o:item>ink</o:item></o:order>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:o="urn:orders" targetNamespace="urn:orders"><xs:element name="item" type="xs:string"/><xs:element name="order"><xs:complexType><xs:sequence><xs:element ref="o:item"/></xs:sequence></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"element 'item' occurs 2 times, maximum allowed is 1"},
+		},
+		{
+			name:     "Element Form - Invalid Choice Lists Alternatives",
+			xmlInput: `<o:order xmlns:o="urn:orders"><o:box/></o:order>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:o="urn:orders" targetNamespace="urn:orders"><xs:element name="item" type="xs:string"/><xs:element name="order"><xs:complexType><xs:choice><xs:element ref="o:item"/><xs:element name="note" type="xs:string"/></xs:choice></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"element '{urn:orders}box' is not a valid choice, expected one of: {urn:orders}item, {}note"},
+		},
+		{
+			name:     "Attribute Refs - Fixed Value From Reference Site",
+			xmlInput: `<o:order xmlns:o="urn:orders" o:currency="USD"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:o="urn:orders" targetNamespace="urn:orders"><xs:attribute name="currency" type="xs:string"/><xs:element name="order"><xs:complexType><xs:attribute ref="o:currency" fixed="EUR"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    false,
+			errors:   []string{"attribute '{urn:orders}currency': value 'USD' must be equal to the fixed value 'EUR'"},
+		},
+		{
+			name:     "Attribute Refs - Fixed Value From Declaration",
+			xmlInput: `<o:order xmlns:o="urn:orders" o:version="2.0"/>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:o="urn:orders" targetNamespace="urn:orders"><xs:attribute name="version" type="xs:decimal" fixed="2"/><xs:element name="order"><xs:complexType><xs:attribute ref="o:version"/></xs:complexType></xs:element></xs:schema>`,
+			valid:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			xsdReader := bytes.NewReader([]byte(tc.xsdInput))
+			xmlReader := bytes.NewReader([]byte(tc.xmlInput))
+
+			validator, err := NewValidator(xsdReader)
+			if err != nil {
+				t.Fatalf("Failed to create validator: %v", err)
+			}
+
+			result, err := validator.Validate(xmlReader)
+			if err != nil {
+				t.Fatalf("Validation error: %v", err)
+			}
+
+			if result.Valid != tc.valid {
+				t.Errorf("Expected valid=%v, got valid=%v", tc.valid, result.Valid)
+			}
+
+			if !tc.valid && len(tc.errors) > 0 {
+				for _, expectedErr := range tc.errors {
+					found := false
+					for _, actualErr := range result.Errors {
+						if actualErr == expectedErr {
+							found = true
+							break
+						}
+					}
+					if !found {
+						t.Errorf("Expected error: %q, but not found in actual errors: %v", expectedErr, result.Errors)
+					}
+				}
+			}
+
+			// Streaming validation reports the same errors, though not
+			// necessarily in the same order.
+			streamed, err := validator.ValidateStream(strings.NewReader(tc.xmlInput))
+			if err != nil {
+				t.Fatalf("Streaming validation error: %v", err)
+			}
+			expected, actual := slices.Sorted(slices.Values(result.Errors)), slices.Sorted(slices.Values(streamed.Errors))
+			if streamed.Valid != result.Valid || !slices.Equal(expected, actual) {
+				t.Errorf("Expected streaming validation to report %v, got %v", result.Errors, streamed.Errors)
+			}
+		})
+	}
+}
+
+func TestXSDVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		xmlInput   string
+		xsdInput   string
+		version    XSDVersion
+		compileErr string
+		valid      bool
+	}{
+		{
+			name:       "Assert Rejected Under XSD 1.0",
+			xmlInput:   `<range><min>1</min></range>`,
+			xsdInput:   `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="range"><xs:complexType><xs:sequence><xs:element name="min" type="xs:int"/></xs:sequence><xs:assert test="min ge 0"/></xs:complexType></xs:element></xs:schema>`,
+			version:    XSDVersion10,
+			compileErr: "failed to compile XSD: xs:assert is an XSD 1.1 construct and cannot be used with XSD 1.0",
+		},
+		{
+			name:     "Assert Accepted Under XSD 1.1",
+			xmlInput: `<range><min>1</min></range>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="range"><xs:complexType><xs:sequence><xs:element name="min" type="xs:int"/></xs:sequence><xs:assert test="min ge 0"/></xs:complexType></xs:element></xs:schema>`,
+			version:  XSDVersion11,
+			valid:    true,
+		},
+		{
+			name:     "Conditional Inclusion Selects XSD 1.0 Declaration",
+			xmlInput: `<code>abc</code>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:vc="http://www.w3.org/2007/XMLSchema-versioning"><xs:element name="code" type="xs:int" vc:minVersion="1.1"/><xs:element name="code" type="xs:string" vc:maxVersion="1.1"/></xs:schema>`,
+			version:  XSDVersion10,
+			valid:    true,
+		},
+		{
+			name:     "Conditional Inclusion Selects XSD 1.1 Declaration",
+			xmlInput: `<code>abc</code>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:vc="http://www.w3.org/2007/XMLSchema-versioning"><xs:element name="code" type="xs:int" vc:minVersion="1.1"/><xs:element name="code" type="xs:string" vc:maxVersion="1.1"/></xs:schema>`,
+			version:  XSDVersion11,
+			valid:    false,
+		},
+		{
+			name:     "Day Time Duration Rejects Months",
+			xmlInput: `<timeout>P1M</timeout>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="timeout" type="xs:dayTimeDuration"/></xs:schema>`,
+			version:  XSDVersion11,
+			valid:    false,
+		},
+		{
+			name:     "Year Month Duration Ordered By Months",
+			xmlInput: `<term>P1Y6M</term>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="term"><xs:simpleType><xs:restriction base="xs:yearMonthDuration"><xs:maxInclusive value="P18M"/></xs:restriction></xs:simpleType></xs:element></xs:schema>`,
+			version:  XSDVersion11,
+			valid:    true,
+		},
+		{
+			name:     "Year Zero Rejected Under XSD 1.0",
+			xmlInput: `<day>0000-01-01</day>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="day" type="xs:date"/></xs:schema>`,
+			version:  XSDVersion10,
+			valid:    false,
+		},
+		{
+			name:     "Year Zero Accepted Under XSD 1.1",
+			xmlInput: `<day>0000-01-01</day>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="day" type="xs:date"/></xs:schema>`,
+			version:  XSDVersion11,
+			valid:    true,
+		},
+		{
+			name:     "Year -0001 Is 1 BCE Under XSD 1.0",
+			xmlInput: `<day>-0001-02-29</day>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="day" type="xs:date"/></xs:schema>`,
+			version:  XSDVersion10,
+			valid:    true,
+		},
+		{
+			name:     "Year -0001 Is 2 BCE Under XSD 1.1",
+			xmlInput: `<day>-0001-02-29</day>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="day" type="xs:date"/></xs:schema>`,
+			version:  XSDVersion11,
+			valid:    false,
+		},
+		{
+			name:     "Year -0004 Is 5 BCE Under XSD 1.0",
+			xmlInput: `<day>-0004-02-29</day>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="day" type="xs:date"/></xs:schema>`,
+			version:  XSDVersion10,
+			valid:    false,
+		},
+		{
+			name:     "Year -0004 Is 4 BCE Under XSD 1.1",
+			xmlInput: `<day>-0004-02-29</day>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="day" type="xs:date"/></xs:schema>`,
+			version:  XSDVersion11,
+			valid:    true,
+		},
+		{
+			name:     "Year Beyond Supported Range",
+			xmlInput: `<day>99999999999-01-01</day>`,
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="day" type="xs:date"/></xs:schema>`,
+			version:  XSDVersion11,
+			valid:    false,
+		},
+		{
+			name:       "Unsupported Version",
+			xsdInput:   `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"/>`,
+			version:    XSDVersion("2.0"),
+			compileErr: `unsupported XSD version: "2.0"`,
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
-			xsdReader := bytes.NewReader([]byte(tc.xsdInput))
-			xmlReader := bytes.NewReader([]byte(tc.xmlInput))
-
-			validator, err := NewValidator(xsdReader)
+			validator, err := NewValidator(bytes.NewReader([]byte(tc.xsdInput)), WithXSDVersion(tc.version))
+			if tc.compileErr != "" {
+				if err == nil || err.Error() != tc.compileErr {
+					t.Fatalf("Expected compile error %q, got %v", tc.compileErr, err)
+				}
+				return
+			}
 			if err != nil {
 				t.Fatalf("Failed to create validator: %v", err)
 			}
 
-			result, err := validator.Validate(xmlReader)
+			result, err := validator.Validate(bytes.NewReader([]byte(tc.xmlInput)))
 			if err != nil {
 				t.Fatalf("Validation error: %v", err)
 			}
-
 			if result.Valid != tc.valid {
-				t.Errorf("Expected valid=%v, got valid=%v", tc.valid, result.Valid)
+				t.Errorf("Expected valid=%v, got valid=%v (errors: %v)", tc.valid, result.Valid, result.Errors)
 			}
+		})
+	}
+}
 
-			if !tc.valid && len(tc.errors) > 0 {
-				for _, expectedErr := range tc.errors {
-					found := false
-					for _, actualErr := range result.Errors {
-						if actualErr == expectedErr {
-							found = true
-							break
-						}
-					}
-					if !found {
-						t.Errorf("Expected error: %q, but not found in actual errors: %v", expectedErr, result.Errors)
-					}
-				}
+func TestGlobalElementInTargetNamespace(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:orders"><xs:element name="order" type="xs:string"/></xs:schema>`
+
+	validator, err := NewValidator(bytes.NewReader([]byte(xsdInput)))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	_, err = validator.Validate(bytes.NewReader([]byte(`<order>pen</order>`)))
+	expected := "root element '{}order' not defined in schema"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+}
+
+func TestImportedAttributeReference(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:m="urn:meta"><xs:import namespace="urn:meta" schemaLocation="meta.xsd"/><xs:element name="doc"><xs:complexType><xs:attribute ref="m:owner" use="required"/></xs:complexType></xs:element></xs:schema>`
+	imported := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:meta"><xs:attribute name="owner" type="xs:NCName"/></xs:schema>`
+
+	var locations []string
+	resolver := func(namespace, location string) (io.Reader, error) {
+		locations = append(locations, location)
+		return strings.NewReader(imported), nil
+	}
+	validator, err := NewValidator(strings.NewReader(xsdInput), WithSchemaResolver(resolver))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	if len(locations) != 1 || locations[0] != "meta.xsd" {
+		t.Errorf("Expected the import to be resolved once from meta.xsd, got %v", locations)
+	}
+
+	for xmlInput, expected := range map[string]string{
+		`<doc xmlns:m="urn:meta" m:owner="alice"/>`: "",
+		`<doc xmlns:m="urn:meta" m:owner="1a"/>`:    "attribute '{urn:meta}owner': invalid NCName value: 1a",
+		`<doc/>`:                                    "missing required attribute '{urn:meta}owner'",
+	} {
+		result, err := validator.Validate(strings.NewReader(xmlInput))
+		if err != nil {
+			t.Fatalf("Validation error: %v", err)
+		}
+		if strings.Join(result.Errors, "\n") != expected {
+			t.Errorf("%s: expected errors %q, got %v", xmlInput, expected, result.Errors)
+		}
+	}
+}
+
+func TestImportedAttributeType(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:b="urn:codes"><xs:import namespace="urn:codes"/><xs:simpleType name="code"><xs:restriction base="xs:int"/></xs:simpleType><xs:element name="doc"><xs:complexType><xs:attribute ref="b:code"/><xs:attribute ref="b:tag"/></xs:complexType></xs:element></xs:schema>`
+	imported := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:b="urn:codes" targetNamespace="urn:codes">
+		<xs:simpleType name="code"><xs:restriction base="xs:string"><xs:pattern value="[A-Z]{3}"/></xs:restriction></xs:simpleType>
+		<xs:attribute name="code" type="b:code"/>
+		<xs:attribute name="tag"><xs:simpleType><xs:restriction base="xs:string"><xs:pattern value="#[a-z]+"/></xs:restriction></xs:simpleType></xs:attribute>
+	</xs:schema>`
+
+	resolver := func(namespace, location string) (io.Reader, error) {
+		return strings.NewReader(imported), nil
+	}
+	validator, err := NewValidator(strings.NewReader(xsdInput), WithSchemaResolver(resolver))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	// The patterns of the imported schema are compiled with the schema.
+	for _, pattern := range []string{"[A-Z]{3}", "#[a-z]+"} {
+		if _, ok := validator.patterns.patterns[pattern]; !ok {
+			t.Errorf("Expected pattern %s to be compiled with the schema", pattern)
+		}
+	}
+
+	for xmlInput, expected := range map[string]string{
+		`<doc xmlns:b="urn:codes" b:code="ABC" b:tag="#go"/>`: "",
+		`<doc xmlns:b="urn:codes" b:code="123"/>`:             "attribute '{urn:codes}code': value does not match pattern: [A-Z]{3}",
+		`<doc xmlns:b="urn:codes" b:tag="go"/>`:               "attribute '{urn:codes}tag': value does not match pattern: #[a-z]+",
+	} {
+		result, err := validator.Validate(strings.NewReader(xmlInput))
+		if err != nil {
+			t.Fatalf("Validation error: %v", err)
+		}
+		if strings.Join(result.Errors, "\n") != expected {
+			t.Errorf("%s: expected errors %q, got %v", xmlInput, expected, result.Errors)
+		}
+	}
+}
+
+func TestDefaultValues(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:o="urn:orders" targetNamespace="urn:orders" elementFormDefault="qualified">
+		<xs:attribute name="currency" type="xs:string" default="EUR"/>
+		<xs:element name="order">
+			<xs:complexType>
+				<xs:sequence>
+					<xs:element name="quantity" type="xs:int" default="1" maxOccurs="unbounded"/>
+				</xs:sequence>
+				<xs:attribute name="priority" type="xs:string" default="normal"/>
+				<xs:attribute name="version" type="xs:decimal" fixed="2"/>
+				<xs:attribute name="note" type="xs:string"/>
+				<xs:attribute ref="o:currency"/>
+			</xs:complexType>
+		</xs:element>
+	</xs:schema>`
+	xmlInput := `<order xmlns="urn:orders" priority="high"><quantity/><quantity>3</quantity></order>`
+
+	validator, err := NewValidator(strings.NewReader(xsdInput), WithDefaults())
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	result, err := validator.Validate(strings.NewReader(xmlInput))
+	if err != nil {
+		t.Fatalf("Validation error: %v", err)
+	}
+	if !result.Valid {
+		t.Fatalf("Expected valid XML, got errors: %v", result.Errors)
+	}
+
+	root := result.Document
+	if len(root.DefaultedAttributes) != 2 || !root.DefaultedAttributes["version"] || !root.DefaultedAttributes["{urn:orders}currency"] {
+		t.Errorf("Expected version and currency to be defaulted, got %v", root.DefaultedAttributes)
+	}
+	if root.Attributes["priority"] != "high" {
+		t.Errorf("Expected the given priority to be kept, got %q", root.Attributes["priority"])
+	}
+	if !root.Children[0].Defaulted || root.Children[1].Defaulted {
+		t.Errorf("Expected only the empty quantity to be defaulted")
+	}
+
+	var out bytes.Buffer
+	if err := WriteXML(&out, root); err != nil {
+		t.Fatalf("Failed to write XML: %v", err)
+	}
+	expected := xml.Header + `<order xmlns="urn:orders" xmlns:ns1="urn:orders" priority="high" version="2" ns1:currency="EUR">` +
+		`<quantity>1</quantity><quantity>3</quantity></order>` + "\n"
+	if out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+
+	// Without the option the document is not augmented.
+	validator, err = NewValidator(strings.NewReader(xsdInput))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	result, err = validator.Validate(strings.NewReader(xmlInput))
+	if err != nil {
+		t.Fatalf("Validation error: %v", err)
+	}
+	if !result.Valid || result.Document != nil {
+		t.Errorf("Expected a valid result without a document, got %v", result.Errors)
+	}
+}
+
+func TestWriteXML(t *testing.T) {
+	xmlInput := `<p xmlns="urn:text">Hello <b>big</b> world<!-- note --><?render fast?> &amp; more</p>`
+	root, err := ParseXML(strings.NewReader(xmlInput))
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+	var out bytes.Buffer
+	if err := WriteXML(&out, root); err != nil {
+		t.Fatalf("Failed to write XML: %v", err)
+	}
+	if expected := xml.Header + xmlInput + "\n"; out.String() != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, out.String())
+	}
+
+	// Without recorded nodes the position of the text is unknown.
+	root = &XMLNode{Name: "p", RawContent: "Hello", Children: []*XMLNode{{Name: "b"}}}
+	expected := "element p has both character data and child elements in unknown order"
+	if err := WriteXML(&out, root); err == nil || err.Error() != expected {
+		t.Errorf("Expected error %q, got %v", expected, err)
+	}
+}
+
+func TestInfoset(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+		<xs:simpleType name="sizeType">
+			<xs:union memberTypes="xs:int">
+				<xs:simpleType><xs:restriction base="xs:token"><xs:enumeration value="small"/></xs:restriction></xs:simpleType>
+			</xs:union>
+		</xs:simpleType>
+		<xs:element name="order">
+			<xs:complexType>
+				<xs:sequence>
+					<xs:element name="price" type="xs:decimal"/>
+					<xs:element name="quantity" type="xs:int" maxOccurs="unbounded"/>
+					<xs:element name="size" type="sizeType" maxOccurs="unbounded"/>
+					<xs:element name="shipped" type="xs:dateTime"/>
+					<xs:element name="tags"><xs:simpleType><xs:list itemType="xs:boolean"/></xs:simpleType></xs:element>
+				</xs:sequence>
+				<xs:attribute name="code" type="xs:hexBinary"/>
+			</xs:complexType>
+		</xs:element>
+	</xs:schema>`
+	xmlInput := `<order code="0FA0"><price> 12.50 </price><quantity>3</quantity><quantity>x</quantity>` +
+		`<size>small</size><size>42</size><shipped>2024-03-01T10:00:00+02:00</shipped><tags>true 0</tags><extra/></order>`
+
+	validator, err := NewValidator(strings.NewReader(xsdInput), WithInfoset())
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	result, err := validator.Validate(strings.NewReader(xmlInput))
+	if err != nil {
+		t.Fatalf("Validation error: %v", err)
+	}
+
+	root := result.Document
+	if root.Info.Validity != ValidityInvalid || root.Info.Attempted != AttemptedPartial {
+		t.Errorf("Expected the root to be invalid and partially assessed, got %s, %s", root.Info.Validity, root.Info.Attempted)
+	}
+	if code := root.Info.Attributes["code"]; code == nil || !bytes.Equal(code.Value.([]byte), []byte{0x0F, 0xA0}) {
+		t.Errorf("Expected the code attribute to be decoded, got %+v", code)
+	}
+
+	children := root.Children
+	if price := children[0].Info; price.NormalizedValue != "12.50" || price.Value.(*big.Rat).Cmp(big.NewRat(25, 2)) != 0 {
+		t.Errorf("Expected price 12.50, got %q %v", price.NormalizedValue, price.Value)
+	}
+	if quantity := children[1].Info; quantity.Type.Name.Local != "int" || quantity.Value.(*big.Int).Int64() != 3 {
+		t.Errorf("Expected quantity 3 of type int, got %v %v", quantity.Type.Name, quantity.Value)
+	}
+	if quantity := children[2].Info; quantity.Validity != ValidityInvalid || quantity.Value != nil {
+		t.Errorf("Expected an invalid quantity without a value, got %+v", quantity)
+	}
+	if size := children[3].Info; size.MemberType != "#2" || size.Value != "small" {
+		t.Errorf("Expected the anonymous member to match small, got %q %v", size.MemberType, size.Value)
+	}
+	if size := children[4].Info; size.MemberType != "xs:int" || size.Value.(*big.Int).Int64() != 42 {
+		t.Errorf("Expected xs:int to match 42, got %q %v", size.MemberType, size.Value)
+	}
+	if shipped := children[5].Info.Value.(time.Time); !shipped.Equal(time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the time to be normalized to UTC, got %v", shipped)
+	}
+	if tags := children[6].Info; children[6].Info.Type.SimpleType == nil || fmt.Sprint(tags.Value) != "[true false]" {
+		t.Errorf("Expected the anonymous list type with values [true false], got %v", tags.Value)
+	}
+	if children[7].Info != nil {
+		t.Errorf("Expected the undeclared element not to be assessed")
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+		<xs:element name="order">
+			<xs:complexType>
+				<xs:sequence>
+					<xs:element name="item" maxOccurs="unbounded">
+						<xs:complexType>
+							<xs:sequence>
+								<xs:element name="price" type="xs:decimal"/>
+								<xs:element name="weight" type="xs:float"/>
+								<xs:element name="sizes"><xs:simpleType><xs:list itemType="xs:int"/></xs:simpleType></xs:element>
+							</xs:sequence>
+							<xs:attribute name="gift" type="xs:boolean"/>
+						</xs:complexType>
+					</xs:element>
+					<xs:element name="note" minOccurs="0"><xs:complexType/></xs:element>
+				</xs:sequence>
+			</xs:complexType>
+		</xs:element>
+	</xs:schema>`
+	xmlInput := `<order><item gift="1"><price>+012.50</price><weight>NaN</weight><sizes> 1  2 </sizes></item><note/></order>`
+
+	validator, err := NewValidator(strings.NewReader(xsdInput), WithInfoset())
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	result, err := validator.Validate(strings.NewReader(xmlInput))
+	if err != nil {
+		t.Fatalf("Validation error: %v", err)
+	}
+	if !result.Valid {
+		t.Fatalf("Expected valid XML, got errors: %v", result.Errors)
+	}
+
+	output, err := ToJSON(result.Document, JSONConvention{AttributePrefix: "_", TextKey: "value"})
+	if err != nil {
+		t.Fatalf("Failed to convert to JSON: %v", err)
+	}
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, output); err != nil {
+		t.Fatalf("Invalid JSON: %v", err)
+	}
+	expected := `{"order":{"item":[{"_gift":true,"price":12.5,"weight":"NaN","sizes":[1,2]}],"note":{}}}`
+	if compact.String() != expected {
+		t.Errorf("Expected %s, got %s", expected, compact.String())
+	}
+
+	if _, err := ToJSON(&XMLNode{Name: "order"}, DefaultJSONConvention); err == nil {
+		t.Errorf("Expected an error for a document without validation outcome")
+	}
+}
+
+func TestToJSONRepeatableChoice(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+		<xs:element name="list">
+			<xs:complexType>
+				<xs:choice maxOccurs="unbounded">
+					<xs:element name="a" type="xs:int"/>
+					<xs:element name="b" type="xs:string"/>
+				</xs:choice>
+			</xs:complexType>
+		</xs:element>
+	</xs:schema>`
+	validator, err := NewValidator(strings.NewReader(xsdInput), WithInfoset())
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	// Elements of a repeatable choice are arrays however often they occur.
+	for xmlInput, expected := range map[string]string{
+		`<list><a>1</a></list>`:                 `{"list":{"a":[1]}}`,
+		`<list><a>1</a><a>2</a></list>`:         `{"list":{"a":[1,2]}}`,
+		`<list><b>x</b><a>1</a><b>y</b></list>`: `{"list":{"b":["x","y"],"a":[1]}}`,
+	} {
+		result, err := validator.Validate(strings.NewReader(xmlInput))
+		if err != nil {
+			t.Fatalf("Validation error: %v", err)
+		}
+		output, err := ToJSON(result.Document, DefaultJSONConvention)
+		if err != nil {
+			t.Fatalf("Failed to convert to JSON: %v", err)
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, output); err != nil {
+			t.Fatalf("Invalid JSON: %v", err)
+		}
+		if compact.String() != expected {
+			t.Errorf("%s: expected %s, got %s", xmlInput, expected, compact.String())
+		}
+	}
+}
+
+func TestToJSONKeyCollision(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:b="urn:b">
+		<xs:import namespace="urn:b"/>
+		<xs:element name="doc">
+			<xs:complexType>
+				<xs:sequence>
+					<xs:element name="id" type="xs:string"/>
+				</xs:sequence>
+				<xs:attribute name="id" type="xs:string"/>
+				<xs:attribute ref="b:id"/>
+			</xs:complexType>
+		</xs:element>
+	</xs:schema>`
+	imported := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:b"><xs:attribute name="id" type="xs:string"/></xs:schema>`
+	validator, err := NewValidator(strings.NewReader(xsdInput), WithInfoset(), WithSchemaResolver(func(namespace, location string) (io.Reader, error) {
+		return strings.NewReader(imported), nil
+	}))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	tests := []struct {
+		xmlInput   string
+		convention JSONConvention
+		expected   string
+	}{
+		{
+			xmlInput:   `<doc id="1" xmlns:b="urn:b" b:id="2"><id>3</id></doc>`,
+			convention: DefaultJSONConvention,
+			expected:   `element /doc[1]: more than one attribute, child element or text has the JSON key "@id"`,
+		},
+		{
+			xmlInput:   `<doc id="1"><id>3</id></doc>`,
+			convention: JSONConvention{TextKey: "#text"},
+			expected:   `element /doc[1]: more than one attribute, child element or text has the JSON key "id"`,
+		},
+	}
+	for _, tc := range tests {
+		result, err := validator.Validate(strings.NewReader(tc.xmlInput))
+		if err != nil {
+			t.Fatalf("Validation error: %v", err)
+		}
+		if !result.Valid {
+			t.Fatalf("%s: expected valid XML, got errors: %v", tc.xmlInput, result.Errors)
+		}
+		if _, err := ToJSON(result.Document, tc.convention); err == nil || err.Error() != tc.expected {
+			t.Errorf("%s: expected error %q, got %v", tc.xmlInput, tc.expected, err)
+		}
+	}
+}
+
+func TestValidationErrorDetails(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" targetNamespace="urn:orders" elementFormDefault="qualified">
+		<xs:simpleType name="skuType">
+			<xs:restriction base="xs:string"><xs:pattern value="[A-Z]{2}-\d+"/></xs:restriction>
+		</xs:simpleType>
+		<xs:element name="order">
+			<xs:complexType>
+				<xs:sequence>
+					<xs:element name="item" maxOccurs="unbounded">
+						<xs:complexType>
+							<xs:sequence><xs:element name="quantity" type="xs:positiveInteger"/></xs:sequence>
+							<xs:attribute name="sku" type="skuType" use="required"/>
+						</xs:complexType>
+					</xs:element>
+				</xs:sequence>
+			</xs:complexType>
+		</xs:element>
+	</xs:schema>`
+	xmlInput := `<po:order xmlns:po="urn:orders">
+  <po:item sku="AB-1"><po:quantity>1</po:quantity></po:item>
+  <po:item
+      sku="ab"><po:quantity>0</po:quantity><po:note/></po:item>
+  <po:item/></po:order>`
+
+	validator, err := NewValidator(strings.NewReader(xsdInput))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	result, err := validator.Validate(strings.NewReader(xmlInput))
+	if err != nil {
+		t.Fatalf("Validation error: %v", err)
+	}
+
+	expected := []ValidationError{
+		{Code: "cvc-pattern-valid", Path: "/po:order[1]/po:item[2]/@sku", Line: 4, Column: 7,
+			Component: "attribute sku", Expected: `[A-Z]{2}-\d+`, Actual: "ab"},
+		{Code: "cvc-datatype-valid.1.2.1", Path: "/po:order[1]/po:item[2]/po:quantity[1]", Line: 4, Column: 16,
+			Component: "element quantity", Expected: "positiveInteger", Actual: "0"},
+		{Code: "cvc-complex-type.2.4.a", Path: "/po:order[1]/po:item[2]/po:note[1]", Line: 4, Column: 44,
+			Component: "sequence", Actual: "note"},
+		{Code: "cvc-complex-type.4", Path: "/po:order[1]/po:item[3]", Line: 5, Column: 3,
+			Component: "attribute sku"},
+		{Code: "cvc-complex-type.2.4.b", Path: "/po:order[1]/po:item[3]", Line: 5, Column: 3,
+			Component: "element quantity", Expected: "at least 1", Actual: "0"},
+	}
+	if len(result.Details) != len(expected) || len(result.Errors) != len(expected) {
+		t.Fatalf("Expected %d errors, got %v", len(expected), result.Errors)
+	}
+	for i, e := range expected {
+		got := result.Details[i]
+		if got.Message != result.Errors[i] || got.Severity != SeverityError {
+			t.Errorf("Error %d: expected message %q with severity error, got %q %s", i, result.Errors[i], got.Message, got.Severity)
+		}
+		got.Message, got.Severity = "", ""
+		if got != e {
+			t.Errorf("Error %d: expected %#v, got %#v", i, e, got)
+		}
+	}
+}
+
+func TestParseXMLPositions(t *testing.T) {
+	xmlInput := "<?xml version=\"1.0\"?>\n<a xmlns:p=\"urn:p\">\n  <b x=\"1\"\n     p:y='2'/>\n</a>"
+
+	root, err := ParseXML(strings.NewReader(xmlInput))
+	if err != nil {
+		t.Fatalf("Failed to parse XML: %v", err)
+	}
+	if root.Start != (Position{Line: 2, Column: 1, Offset: 22}) || root.End.Offset != int64(len(xmlInput)) {
+		t.Errorf("Unexpected root positions %+v - %+v", root.Start, root.End)
+	}
+
+	b := root.Children[0]
+	if b.Start != (Position{Line: 3, Column: 3, Offset: 44}) || b.End != (Position{Line: 4, Column: 15, Offset: 67}) {
+		t.Errorf("Unexpected element positions %+v - %+v", b.Start, b.End)
+	}
+	if span := b.AttributeSpans["x"]; span != (Span{Start: Position{Line: 3, Column: 6, Offset: 47}, End: Position{Line: 3, Column: 11, Offset: 52}}) {
+		t.Errorf("Unexpected span of x: %+v", span)
+	}
+	if span := b.AttributeSpans["{urn:p}y"]; span != (Span{Start: Position{Line: 4, Column: 6, Offset: 58}, End: Position{Line: 4, Column: 13, Offset: 65}}) {
+		t.Errorf("Unexpected span of p:y: %+v", span)
+	}
+	if span := b.AttributeSpans["x"]; xmlInput[span.Start.Offset:span.End.Offset] != `x="1"` {
+		t.Errorf("Expected the span of x to cover the attribute, got %q", xmlInput[span.Start.Offset:span.End.Offset])
+	}
+	if len(root.AttributeSpans) != 0 {
+		t.Errorf("Expected namespace declarations to have no span, got %v", root.AttributeSpans)
+	}
+}
+
+// itemsReader generates a document with n item elements without holding it
+// in memory. The item at position bad has an invalid value.
+type itemsReader struct {
+	n, bad, next int
+	buf          []byte
+}
+
+func (r *itemsReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		switch {
+		case r.next == 0:
+			r.buf = []byte("<list>\n")
+		case r.next <= r.n && r.next == r.bad:
+			r.buf = []byte("  <item>x</item>\n")
+		case r.next <= r.n:
+			r.buf = fmt.Appendf(nil, "  <item>%d</item>\n", r.next)
+		case r.next == r.n+1:
+			r.buf = []byte("</list>")
+		default:
+			return 0, io.EOF
+		}
+		r.next++
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// heapReader reads from r and records the largest heap in use after a
+// garbage collection, sampled every 1000 reads.
+type heapReader struct {
+	r     io.Reader
+	reads int
+	peak  uint64
+}
+
+func (h *heapReader) Read(p []byte) (int, error) {
+	h.reads++
+	if h.reads%1000 == 0 {
+		runtime.GC()
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		h.peak = max(h.peak, stats.HeapAlloc)
+	}
+	return h.r.Read(p)
+}
+
+func TestValidateStream(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+		<xs:element name="list">
+			<xs:complexType>
+				<xs:sequence><xs:element name="item" type="xs:int" maxOccurs="unbounded"/></xs:sequence>
+			</xs:complexType>
+		</xs:element>
+	</xs:schema>`
+	validator, err := NewValidator(strings.NewReader(xsdInput))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+
+	result, err := validator.ValidateStream(&itemsReader{n: 20000, bad: 15000})
+	if err != nil {
+		t.Fatalf("Validation error: %v", err)
+	}
+	if result.Valid || len(result.Details) != 1 {
+		t.Fatalf("Expected a single error, got %v", result.Errors)
+	}
+	if e := result.Details[0]; e.Path != "/list[1]/item[15000]" || e.Line != 15001 || e.Column != 3 {
+		t.Errorf("Expected the error at /list[1]/item[15000] on line 15001, got %s at %d:%d", e.Path, e.Line, e.Column)
+	}
+
+	// The heap in use must not grow with the number of items.
+	var peaks []uint64
+	for _, n := range []int{10000, 100000} {
+		input := &heapReader{r: &itemsReader{n: n}}
+		if result, err := validator.ValidateStream(input); err != nil || !result.Valid {
+			t.Fatalf("Expected %d items to be valid, got %v %v", n, err, result)
+		}
+		peaks = append(peaks, input.peak)
+	}
+	if peaks[1] > peaks[0]+1<<20 {
+		t.Errorf("Expected the heap to stay bounded, got %d bytes for 10000 items and %d bytes for 100000 items", peaks[0], peaks[1])
+	}
+
+	if _, err := validator.ValidateStream(strings.NewReader(`<list><item>1</item>`)); err == nil {
+		t.Errorf("Expected an error for a truncated document")
+	}
+	if _, err := validator.ValidateStream(strings.NewReader(`<other/>`)); err == nil {
+		t.Errorf("Expected an error for an undeclared root element")
+	}
+}
+
+func TestURIPolicy(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="link" type="xs:anyURI"/></xs:schema>`
+
+	tests := []struct {
+		name     string
+		xmlInput string
+		opts     []Option
+		errors   []string
+	}{
+		{
+			name:     "Relative Reference Accepted By Default",
+			xmlInput: `<link>../a.xml</link>`,
+		},
+		{
+			name:     "Relative Reference Rejected When Absolute Required",
+			xmlInput: `<link>../a.xml</link>`,
+			opts:     []Option{WithAbsoluteURIs()},
+			errors:   []string{"invalid content in element 'link': invalid anyURI value: ../a.xml: relative reference is not allowed"},
+		},
+		{
+			name:     "Allowed Scheme",
+			xmlInput: `<link>HTTPS://example.com/</link>`,
+			opts:     []Option{WithURISchemes("https", "urn")},
+		},
+		{
+			name:     "Disallowed Scheme",
+			xmlInput: `<link>ftp://example.com/</link>`,
+			opts:     []Option{WithURISchemes("https", "urn")},
+			errors:   []string{"invalid content in element 'link': invalid anyURI value: ftp://example.com/: scheme ftp is not allowed"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			validator, err := NewValidator(bytes.NewReader([]byte(xsdInput)), tc.opts...)
+			if err != nil {
+				t.Fatalf("Failed to create validator: %v", err)
+			}
+			result, err := validator.Validate(bytes.NewReader([]byte(tc.xmlInput)))
+			if err != nil {
+				t.Fatalf("Validation error: %v", err)
+			}
+			if strings.Join(result.Errors, "\n") != strings.Join(tc.errors, "\n") {
+				t.Errorf("Expected errors %v, got %v", tc.errors, result.Errors)
+			}
+		})
+	}
+}
+
+func TestInvalidPatternCompileError(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="codeType"><xs:restriction base="xs:string"><xs:pattern value="(?i)abc"/></xs:restriction></xs:simpleType><xs:element name="code" type="codeType"/></xs:schema>`
+
+	_, err := NewValidator(bytes.NewReader([]byte(xsdInput)))
+	if err == nil {
+		t.Fatal("Expected a compile error for a Perl-only pattern")
+	}
+	expected := `failed to compile XSD: invalid pattern "(?i)abc": at offset 1: groups starting with '(?' are not allowed`
+	if err.Error() != expected {
+		t.Errorf("Expected error %q, got %q", expected, err.Error())
+	}
+}
+
+func TestUnionMemberType(t *testing.T) {
+	xsdInput := `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="idType"><xs:union memberTypes="xs:int xs:date"><xs:simpleType><xs:restriction base="xs:token"/></xs:simpleType></xs:union></xs:simpleType></xs:schema>`
+
+	validator, err := NewValidator(bytes.NewReader([]byte(xsdInput)))
+	if err != nil {
+		t.Fatalf("Failed to create validator: %v", err)
+	}
+	union := validator.findSimpleType("idType").Union
+
+	for value, expected := range map[string]string{"42": "xs:int", "2024-01-31": "xs:date", "A-1": "#3"} {
+		member, err := validator.matchUnionMember(value, union, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error for %q: %v", value, err)
+		}
+		if member != expected {
+			t.Errorf("Expected %q to match member %s, got %s", value, expected, member)
+		}
+	}
+}
+
+func TestFacetDerivationCompileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		xsdInput string
+		expected string
+	}{
+		{
+			name:     "Max Length Widened",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="shortCode"><xs:restriction base="xs:string"><xs:maxLength value="5"/></xs:restriction></xs:simpleType><xs:simpleType name="longCode"><xs:restriction base="shortCode"><xs:maxLength value="10"/></xs:restriction></xs:simpleType></xs:schema>`,
+			expected: "failed to compile XSD: simple type longCode: maxLength 10 is greater than the base type's maxLength 5",
+		},
+		{
+			name:     "Built-in Range Widened",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="level"><xs:restriction base="xs:byte"><xs:maxInclusive value="200"/></xs:restriction></xs:simpleType></xs:schema>`,
+			expected: "failed to compile XSD: simple type level: maxInclusive 200 is outside the base type's maxInclusive 127",
+		},
+		{
+			name:     "Fraction Digits On Integer",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="count"><xs:restriction base="xs:integer"><xs:fractionDigits value="2"/></xs:restriction></xs:simpleType></xs:schema>`,
+			expected: "failed to compile XSD: simple type count: facet fractionDigits is fixed to 0 in the base type xs:integer",
+		},
+		{
+			name:     "Range Facet On String",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="name"><xs:restriction base="xs:string"><xs:maxInclusive value="z"/></xs:restriction></xs:simpleType></xs:schema>`,
+			expected: "failed to compile XSD: simple type name: facet maxInclusive is not applicable to the base type xs:string",
+		},
+		{
+			name:     "Attribute Reference Not Found",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="order"><xs:complexType><xs:attribute ref="version"/></xs:complexType></xs:element></xs:schema>`,
+			expected: "failed to compile XSD: referenced attribute not found: version",
+		},
+		{
+			name:     "Attribute Reference Changes Fixed Value",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:attribute name="version" type="xs:int" fixed="2"/><xs:element name="order"><xs:complexType><xs:attribute ref="version" fixed="3"/></xs:complexType></xs:element></xs:schema>`,
+			expected: "failed to compile XSD: attribute reference version must keep the fixed value 2 of its declaration",
+		},
+		{
+			name:     "Required Attribute With Default",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="order"><xs:complexType><xs:attribute name="priority" type="xs:string" use="required" default="normal"/></xs:complexType></xs:element></xs:schema>`,
+			expected: "failed to compile XSD: attribute priority has a default value and must be optional",
+		},
+		{
+			name:     "NOTATION Enumeration Without Declaration",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:notation name="png" public="image/png"/><xs:simpleType name="formatType"><xs:restriction base="xs:NOTATION"><xs:enumeration value="gif"/></xs:restriction></xs:simpleType></xs:schema>`,
+			expected: "failed to compile XSD: enumeration value gif is not a declared notation",
+		},
+		{
+			name:     "White Space Relaxed",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="code"><xs:restriction base="xs:token"><xs:whiteSpace value="preserve"/></xs:restriction></xs:simpleType></xs:schema>`,
+			expected: "failed to compile XSD: simple type code: whiteSpace preserve is less restrictive than the base type's whiteSpace collapse",
+		},
+		{
+			name:     "Circular Derivation",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="a"><xs:restriction base="b"/></xs:simpleType><xs:simpleType name="b"><xs:restriction base="a"/></xs:simpleType></xs:schema>`,
+			expected: "failed to compile XSD: simple type a: circular derivation of type a",
+		},
+		{
+			name:     "Undefined Base Type",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="a"><xs:restriction base="missing"/></xs:simpleType></xs:schema>`,
+			expected: "failed to compile XSD: simple type a: type missing is not defined",
+		},
+		{
+			name:     "Undefined Element Type",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="a" type="Undefined"/></xs:schema>`,
+			expected: "failed to compile XSD: element a: type Undefined is not defined",
+		},
+		{
+			name:     "Element Type In Another Namespace",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema" xmlns:b="urn:b" targetNamespace="urn:a"><xs:simpleType name="S"><xs:restriction base="xs:int"/></xs:simpleType><xs:element name="a" type="b:S"/></xs:schema>`,
+			expected: "failed to compile XSD: element a: type b:S is not defined",
+		},
+		{
+			name:     "Undefined Attribute Type",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:element name="a"><xs:complexType><xs:attribute name="code" type="codeType"/></xs:complexType></xs:element></xs:schema>`,
+			expected: "failed to compile XSD: attribute code: type codeType is not defined",
+		},
+		{
+			name:     "Undefined List Item Type",
+			xsdInput: `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema"><xs:simpleType name="codes"><xs:list itemType="code"/></xs:simpleType></xs:schema>`,
+			expected: "failed to compile XSD: simple type codes: type code is not defined",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewValidator(bytes.NewReader([]byte(tc.xsdInput)))
+			if err == nil || err.Error() != tc.expected {
+				t.Errorf("Expected compile error %q, got %v", tc.expected, err)
 			}
 		})
 	}
@@ -0,0 +1,134 @@
+package pkg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+const (
+	xsdNamespace        = "http://www.w3.org/2001/XMLSchema"
+	versioningNamespace = "http://www.w3.org/2007/XMLSchema-versioning"
+)
+
+// XSDVersion selects the version of the XML Schema language used to compile
+// the schema and validate documents.
+type XSDVersion string
+
+const (
+	// XSDVersion10 selects XML Schema 1.0 semantics.
+	XSDVersion10 XSDVersion = "1.0"
+	// XSDVersion11 selects XML Schema 1.1 semantics.
+	XSDVersion11 XSDVersion = "1.1"
+)
+
+// xsd11Constructs lists schema components that only exist in XSD 1.1.
+var xsd11Constructs = map[string]bool{
+	"assert":             true,
+	"assertion":          true,
+	"alternative":        true,
+	"openContent":        true,
+	"defaultOpenContent": true,
+	"override":           true,
+	"explicitTimezone":   true,
+}
+
+// number returns the numeric value of the version for comparisons against
+// vc:minVersion and vc:maxVersion.
+func (v XSDVersion) number() (float64, error) {
+	switch v {
+	case XSDVersion10, XSDVersion11:
+		return strconv.ParseFloat(string(v), 64)
+	default:
+		return 0, fmt.Errorf("unsupported XSD version: %q", string(v))
+	}
+}
+
+// versionFilter is an xml.TokenReader that drops schema elements excluded by
+// vc:minVersion/vc:maxVersion conditional inclusion and records the XSD 1.1
+// constructs that remain in the schema.
+type versionFilter struct {
+	decoder *xml.Decoder
+	version float64
+
+	// skip is the depth of the excluded subtree currently being dropped.
+	skip int
+	// constructs holds the XSD 1.1 components found, in document order.
+	constructs []string
+}
+
+func newVersionFilter(d *xml.Decoder, version float64) *versionFilter {
+	return &versionFilter{
+		decoder: d,
+		version: version,
+	}
+}
+
+// Token returns the next token that is not excluded by conditional inclusion.
+func (f *versionFilter) Token() (xml.Token, error) {
+	for {
+		token, err := f.decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			if f.skip > 0 {
+				f.skip++
+				continue
+			}
+			included, err := f.included(t)
+			if err != nil {
+				return nil, err
+			}
+			if !included {
+				f.skip = 1
+				continue
+			}
+			if t.Name.Space == xsdNamespace && xsd11Constructs[t.Name.Local] {
+				f.constructs = append(f.constructs, t.Name.Local)
+			}
+		case xml.EndElement:
+			if f.skip > 0 {
+				f.skip--
+				continue
+			}
+		default:
+			if f.skip > 0 {
+				continue
+			}
+		}
+		return xml.CopyToken(token), nil
+	}
+}
+
+// included reports whether the element takes part in the schema for the
+// selected version. An element is excluded when the version is lower than its
+// vc:minVersion or not lower than its vc:maxVersion.
+func (f *versionFilter) included(t xml.StartElement) (bool, error) {
+	for _, attr := range t.Attr {
+		if attr.Name.Space != versioningNamespace {
+			continue
+		}
+		switch attr.Name.Local {
+		case "minVersion":
+			limit, err := strconv.ParseFloat(attr.Value, 64)
+			if err != nil {
+				return false, fmt.Errorf("invalid vc:minVersion value: %s", attr.Value)
+			}
+			if f.version < limit {
+				return false, nil
+			}
+		case "maxVersion":
+			limit, err := strconv.ParseFloat(attr.Value, 64)
+			if err != nil {
+				return false, fmt.Errorf("invalid vc:maxVersion value: %s", attr.Value)
+			}
+			if f.version >= limit {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
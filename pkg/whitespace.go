@@ -0,0 +1,58 @@
+package pkg
+
+import "strings"
+
+// Values of the whiteSpace facet.
+const (
+	whiteSpacePreserve = "preserve"
+	whiteSpaceReplace  = "replace"
+	whiteSpaceCollapse = "collapse"
+)
+
+// builtinWhiteSpace returns the whiteSpace facet value of a built-in type.
+// Types derived from string inherit the facet of the nearest of string,
+// normalizedString and token; all other built-in types collapse whitespace.
+func builtinWhiteSpace(name string) string {
+	if name == "anySimpleType" {
+		return whiteSpacePreserve
+	}
+	for t := name; t != ""; t = builtinTypes[t].base {
+		switch t {
+		case "token":
+			return whiteSpaceCollapse
+		case "normalizedString":
+			return whiteSpaceReplace
+		case "string":
+			return whiteSpacePreserve
+		}
+		if builtinTypes[t].itemType != "" {
+			return whiteSpaceCollapse
+		}
+	}
+	return whiteSpaceCollapse
+}
+
+// normalizeWhiteSpace applies whiteSpace normalization to a value. With
+// replace, every tab, line feed and carriage return becomes a space; collapse
+// additionally removes leading and trailing spaces and merges runs of spaces.
+func normalizeWhiteSpace(value string, mode string) string {
+	switch mode {
+	case whiteSpaceReplace:
+		return strings.Map(replaceWhiteSpace, value)
+	case whiteSpaceCollapse:
+		fields := strings.FieldsFunc(strings.Map(replaceWhiteSpace, value), func(r rune) bool {
+			return r == ' '
+		})
+		return strings.Join(fields, " ")
+	default:
+		return value
+	}
+}
+
+func replaceWhiteSpace(r rune) rune {
+	switch r {
+	case '\t', '\n', '\r':
+		return ' '
+	}
+	return r
+}
@@ -0,0 +1,157 @@
+package pkg
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+var (
+	textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	attrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;",
+		"\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+// WriteXML serializes a document tree, such as the augmented document of a
+// ValidationResult, as XML. Element and attribute names keep the namespace
+// declarations of the tree; attributes are written in a stable order, and
+// namespaces that have no prefix in scope are declared where they are used.
+//
+// The content of elements is written in document order as recorded in
+// XMLNode.Nodes, so that mixed content, comments and processing
+// instructions are kept; the prolog and anything after the root element are
+// not part of the tree. Elements without Nodes, such as those of trees
+// built by hand, are written from Children and RawContent; WriteXML returns
+// an error if such an element has both child elements and character data,
+// as the position of the text among the children is unknown.
+func WriteXML(w io.Writer, root *XMLNode) error {
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	if err := writeNode(&b, root, map[string]string{}); err != nil {
+		return err
+	}
+	b.WriteString("\n")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeNode(b *strings.Builder, node *XMLNode, inherited map[string]string) error {
+	// The bindings in scope are those of the parent together with the
+	// declarations on this element.
+	bindings := make(map[string]string, len(inherited)+len(node.NamespaceDecls))
+	for prefix, uri := range inherited {
+		bindings[prefix] = uri
+	}
+	decls := make(map[string]string, len(node.NamespaceDecls))
+	for key, uri := range node.NamespaceDecls {
+		prefix := key
+		if key == xmlns {
+			prefix = ""
+		}
+		bindings[prefix] = uri
+		decls[prefix] = uri
+	}
+
+	name := qualifiedName(node.Namespace, node.Name, bindings, decls, true)
+	keys := make([]string, 0, len(node.Attributes))
+	for key := range node.Attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	attrs := make([]string, len(keys))
+	for i, key := range keys {
+		namespace, local := splitAttributeKey(key)
+		attrs[i] = fmt.Sprintf(` %s="%s"`,
+			qualifiedName(namespace, local, bindings, decls, false), attrEscaper.Replace(node.Attributes[key]))
+	}
+
+	b.WriteString("<" + name)
+	prefixes := make([]string, 0, len(decls))
+	for prefix := range decls {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		if prefix == "" {
+			fmt.Fprintf(b, ` xmlns="%s"`, attrEscaper.Replace(decls[prefix]))
+		} else {
+			fmt.Fprintf(b, ` xmlns:%s="%s"`, prefix, attrEscaper.Replace(decls[prefix]))
+		}
+	}
+	b.WriteString(strings.Join(attrs, ""))
+
+	nodes := node.Nodes
+	switch {
+	case nodes == nil:
+		// The order of character data among the children is not recorded.
+		if len(node.Children) > 0 && strings.TrimSpace(node.RawContent) != "" {
+			return fmt.Errorf("element %s has both character data and child elements in unknown order", name)
+		}
+		if len(node.Children) == 0 && node.RawContent != "" {
+			nodes = append(nodes, xml.CharData(node.RawContent))
+		}
+		for _, child := range node.Children {
+			nodes = append(nodes, child)
+		}
+	case node.Defaulted:
+		// Content supplied by a default value is not among the recorded nodes.
+		nodes = append([]xml.Token{xml.CharData(node.RawContent)}, nodes...)
+	}
+
+	if len(nodes) == 0 {
+		b.WriteString("/>")
+		return nil
+	}
+	b.WriteString(">")
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case *XMLNode:
+			if err := writeNode(b, n, bindings); err != nil {
+				return err
+			}
+		case xml.CharData:
+			b.WriteString(textEscaper.Replace(string(n)))
+		case xml.Comment:
+			b.WriteString("<!--" + string(n) + "-->")
+		case xml.ProcInst:
+			b.WriteString("<?" + n.Target)
+			if len(n.Inst) > 0 {
+				b.WriteString(" " + string(n.Inst))
+			}
+			b.WriteString("?>")
+		}
+	}
+	b.WriteString("</" + name + ">")
+	return nil
+}
+
+// qualifiedName returns the name under which an element or attribute in the
+// given namespace is written. If no prefix in scope is bound to the namespace,
+// a binding is added to bindings and decls. Unqualified attributes never take
+// the default namespace, and qualified attributes always need a prefix.
+func qualifiedName(namespace, local string, bindings, decls map[string]string, element bool) string {
+	if !element && namespace == "" {
+		return local
+	}
+	if element && bindings[""] == namespace {
+		return local
+	}
+	if element && namespace == "" {
+		bindings[""], decls[""] = "", ""
+		return local
+	}
+	if prefix, ok := boundPrefix(namespace, bindings); ok {
+		return prefix + ":" + local
+	}
+
+	prefix := ""
+	for i := 1; prefix == ""; i++ {
+		if _, ok := bindings[fmt.Sprintf("ns%d", i)]; !ok {
+			prefix = fmt.Sprintf("ns%d", i)
+		}
+	}
+	bindings[prefix], decls[prefix] = namespace, namespace
+	return prefix + ":" + local
+}
@@ -11,38 +11,150 @@ const xmlns = "xmlns"
 
 // XMLNode node representation of XML
 type XMLNode struct {
-	Name           string
-	Namespace      string
-	Prefix         string
-	Attributes     map[string]string
-	Content        string
-	Children       []*XMLNode
+	Name       string
+	Namespace  string
+	Prefix     string
+	Attributes map[string]string
+	Content    string
+	// RawContent holds the character data of the element exactly as it
+	// appears in the document. Content is the same text with surrounding
+	// whitespace trimmed; whiteSpace normalization is applied per type
+	// during validation.
+	RawContent string
+	Children   []*XMLNode
+	// Nodes holds the content of the element in document order: child
+	// elements as *XMLNode, character data as xml.CharData, comments as
+	// xml.Comment and processing instructions as xml.ProcInst. It is only
+	// recorded by ParseXML.
+	Nodes          []xml.Token
 	NamespaceDecls map[string]string
+	// Path locates the element in the document, such as
+	// /po:order[1]/items[1]/item[3]. Prefixes are taken from the namespace
+	// bindings in scope.
+	Path string
+	// Namespaces holds the namespace bindings in scope for the element: its
+	// own declarations together with those inherited from its ancestors. The
+	// default namespace is bound to the empty prefix.
+	Namespaces map[string]string
+	// Defaulted reports whether the content of the element was supplied by
+	// the default or fixed value of its declaration, and DefaultedAttributes
+	// holds the keys of the attributes supplied the same way. Both are only
+	// set by a validator created WithDefaults.
+	Defaulted           bool
+	DefaultedAttributes map[string]bool
+	// Info holds the outcome of validating the element. It is only set by a
+	// validator created WithInfoset, and is nil for elements that were not
+	// assessed.
+	Info *ElementInfo
+	// Start is the position of the start tag and End the position just
+	// after the end tag. AttributeSpans holds the span of each attribute,
+	// from its name to the closing quote of its value, by attribute key.
+	Start          Position
+	End            Position
+	AttributeSpans map[string]Span
+}
+
+// Position is a location in an XML document. Line and Column start at 1;
+// columns and offsets count bytes.
+type Position struct {
+	Line   int
+	Column int
+	Offset int64
+}
+
+// Span is the part of an XML document from Start up to, but not including,
+// End.
+type Span struct {
+	Start Position
+	End   Position
 }
 
 // ParseXML parses XML document and returns XMLNode
 func ParseXML(r io.Reader) (*XMLNode, error) {
-	decoder := xml.NewDecoder(r)
-	var stack []*XMLNode
+	scanner := newXMLScanner(r)
+	scanner.keepNodes = true
 	var root *XMLNode
-
-	// Track namespaces at each level
-	nsStack := []map[string]string{{}}
-
 	for {
-		token, err := decoder.Token()
+		node, ended, err := scanner.next()
 		if err == io.EOF {
 			break
 		}
 		if err != nil {
 			return nil, err
 		}
+		if ended {
+			continue
+		}
+		if parent := scanner.parent(); parent != nil {
+			parent.Children = append(parent.Children, node)
+			parent.Nodes = append(parent.Nodes, node)
+		} else {
+			root = node
+		}
+	}
+
+	return root, nil
+}
+
+// xmlScanner reads a document one element at a time. It reports every
+// element when its start tag has been read, with its name, attributes and
+// position, and again when its end tag has been read, with its content. Only
+// the open elements are kept, so memory use is proportional to the depth of
+// the document rather than its size.
+type xmlScanner struct {
+	input   *recordingReader
+	decoder *xml.Decoder
+	stack   []*XMLNode
+	// Track namespaces at each level, and the number of children of each
+	// open element by expanded name to number the steps of paths.
+	nsStack  []map[string]string
+	siblings []map[xml.Name]int
+	// keepText reports whether the character data of an open element is
+	// needed. A nil keepText keeps all character data.
+	keepText func(*XMLNode) bool
+	// keepNodes records the character data, comments and processing
+	// instructions of the open elements in XMLNode.Nodes.
+	keepNodes bool
+}
+
+func newXMLScanner(r io.Reader) *xmlScanner {
+	input := &recordingReader{r: r}
+	return &xmlScanner{
+		input:    input,
+		decoder:  xml.NewDecoder(input),
+		nsStack:  []map[string]string{{}},
+		siblings: []map[xml.Name]int{{}},
+	}
+}
+
+// parent returns the parent of the innermost open element, or nil if it is
+// the root element.
+func (s *xmlScanner) parent() *XMLNode {
+	if len(s.stack) < 2 {
+		return nil
+	}
+	return s.stack[len(s.stack)-2]
+}
+
+// next reads the document up to the next start or end tag and returns its
+// element, and whether the tag was an end tag. It returns io.EOF at the end
+// of the document.
+func (s *xmlScanner) next() (*XMLNode, bool, error) {
+	for {
+		// Only the input of the current token is kept.
+		start := inputPosition(s.decoder)
+		s.input.discard(start.Offset)
+		token, err := s.decoder.Token()
+		if err != nil {
+			return nil, false, err
+		}
+		end := inputPosition(s.decoder)
 
 		switch t := token.(type) {
 		case xml.StartElement:
 			// Create new namespace context for this element
 			currentNS := make(map[string]string)
-			for prefix, uri := range nsStack[len(nsStack)-1] {
+			for prefix, uri := range s.nsStack[len(s.nsStack)-1] {
 				currentNS[prefix] = uri
 			}
 
@@ -54,7 +166,7 @@ func ParseXML(r io.Reader) (*XMLNode, error) {
 					currentNS[""] = attr.Value
 				}
 			}
-			nsStack = append(nsStack, currentNS)
+			s.nsStack = append(s.nsStack, currentNS)
 
 			// Resolve element namespace
 			namespace := t.Name.Space
@@ -76,7 +188,14 @@ func ParseXML(r io.Reader) (*XMLNode, error) {
 				Prefix:         t.Name.Space,
 				Attributes:     make(map[string]string),
 				NamespaceDecls: make(map[string]string),
+				Namespaces:     currentNS,
+				Start:          start,
 			}
+			node.AttributeSpans = attributeSpans(s.input.bytes(start.Offset, end.Offset), start, currentNS)
+			s.siblings[len(s.siblings)-1][t.Name]++
+			node.Path = fmt.Sprintf("%s/%s[%d]", parentPath(s.stack), pathStep(namespace, node.Name, currentNS, true),
+				s.siblings[len(s.siblings)-1][t.Name])
+			s.siblings = append(s.siblings, map[xml.Name]int{})
 
 			// Process attributes
 			for _, attr := range t.Attr {
@@ -95,25 +214,173 @@ func ParseXML(r io.Reader) (*XMLNode, error) {
 				}
 			}
 
-			if len(stack) > 0 {
-				parent := stack[len(stack)-1]
-				parent.Children = append(parent.Children, node)
-			} else {
-				root = node
-			}
-			stack = append(stack, node)
+			s.stack = append(s.stack, node)
+			return node, false, nil
 
 		case xml.EndElement:
-			stack = stack[:len(stack)-1]
-			nsStack = nsStack[:len(nsStack)-1]
+			node := s.stack[len(s.stack)-1]
+			node.End = end
+			s.stack = s.stack[:len(s.stack)-1]
+			s.nsStack = s.nsStack[:len(s.nsStack)-1]
+			s.siblings = s.siblings[:len(s.siblings)-1]
+			return node, true, nil
 
 		case xml.CharData:
-			if len(stack) > 0 {
-				current := stack[len(stack)-1]
-				current.Content += strings.TrimSpace(string(t))
+			if len(s.stack) > 0 {
+				current := s.stack[len(s.stack)-1]
+				if s.keepText == nil || s.keepText(current) {
+					current.Content += strings.TrimSpace(string(t))
+					current.RawContent += string(t)
+				}
+				if s.keepNodes {
+					current.Nodes = append(current.Nodes, t.Copy())
+				}
+			}
+
+		case xml.Comment:
+			if s.keepNodes && len(s.stack) > 0 {
+				current := s.stack[len(s.stack)-1]
+				current.Nodes = append(current.Nodes, t.Copy())
+			}
+
+		case xml.ProcInst:
+			if s.keepNodes && len(s.stack) > 0 {
+				current := s.stack[len(s.stack)-1]
+				current.Nodes = append(current.Nodes, t.Copy())
 			}
 		}
 	}
+}
 
-	return root, nil
+func parentPath(stack []*XMLNode) string {
+	if len(stack) == 0 {
+		return ""
+	}
+	return stack[len(stack)-1].Path
+}
+
+// pathStep returns the name of an element or attribute in a path, prefixed
+// with a prefix bound to its namespace.
+func pathStep(namespace, local string, bindings map[string]string, element bool) string {
+	if namespace == "" || element && bindings[""] == namespace {
+		return local
+	}
+	if prefix, ok := boundPrefix(namespace, bindings); ok {
+		return prefix + ":" + local
+	}
+	return attributeKey(namespace, local)
+}
+
+// attributePath returns the path of an attribute of an element.
+func attributePath(node *XMLNode, key string) string {
+	namespace, local := splitAttributeKey(key)
+	return node.Path + "/@" + pathStep(namespace, local, node.Namespaces, false)
+}
+
+func inputPosition(decoder *xml.Decoder) Position {
+	line, column := decoder.InputPos()
+	return Position{Line: line, Column: column, Offset: decoder.InputOffset()}
+}
+
+// attributePosition returns the position of an attribute of an element, or
+// the position of the element for attributes that do not appear in the
+// document.
+func attributePosition(node *XMLNode, key string) Position {
+	if span, ok := node.AttributeSpans[key]; ok {
+		return span.Start
+	}
+	return node.Start
+}
+
+// recordingReader keeps the input the decoder has read but not yet
+// consumed, so that the text of a start tag can be inspected once it has
+// been decoded.
+type recordingReader struct {
+	r   io.Reader
+	buf []byte
+	// base is the input offset of buf[0].
+	base int64
+}
+
+func (rr *recordingReader) Read(p []byte) (int, error) {
+	n, err := rr.r.Read(p)
+	rr.buf = append(rr.buf, p[:n]...)
+	return n, err
+}
+
+// bytes returns the input between two offsets.
+func (rr *recordingReader) bytes(from, to int64) []byte {
+	return rr.buf[from-rr.base : to-rr.base]
+}
+
+// discard drops the input before an offset. The dropped bytes are released
+// when Read next grows the buffer.
+func (rr *recordingReader) discard(offset int64) {
+	rr.buf = rr.buf[offset-rr.base:]
+	rr.base = offset
+}
+
+// attributeSpans returns the spans of the attributes of a start tag, by
+// attribute key. Namespace declarations are left out.
+func attributeSpans(tag []byte, start Position, bindings map[string]string) map[string]Span {
+	spans := make(map[string]Span)
+	pos := start
+	i := 0
+	advance := func() {
+		if tag[i] == '\n' {
+			pos.Line, pos.Column = pos.Line+1, 1
+		} else {
+			pos.Column++
+		}
+		pos.Offset++
+		i++
+	}
+	isSpace := func(c byte) bool {
+		return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+	}
+
+	// Skip the element name.
+	for i < len(tag) && !isSpace(tag[i]) && tag[i] != '/' && tag[i] != '>' {
+		advance()
+	}
+	for i < len(tag) {
+		for i < len(tag) && isSpace(tag[i]) {
+			advance()
+		}
+		if i == len(tag) || tag[i] == '/' || tag[i] == '>' {
+			break
+		}
+		nameStart, from := pos, i
+		for i < len(tag) && tag[i] != '=' && !isSpace(tag[i]) {
+			advance()
+		}
+		name := string(tag[from:i])
+		for i < len(tag) && tag[i] != '"' && tag[i] != '\'' {
+			advance()
+		}
+		if i == len(tag) {
+			break
+		}
+		quote := tag[i]
+		advance()
+		for i < len(tag) && tag[i] != quote {
+			advance()
+		}
+		if i < len(tag) {
+			advance()
+		}
+		span := Span{Start: nameStart, End: pos}
+
+		prefix, local := splitQName(name)
+		switch {
+		case name == xmlns || prefix == xmlns:
+		case prefix == "":
+			spans[local] = span
+		case prefix == "xml":
+			spans[attributeKey(xmlNamespace, local)] = span
+		default:
+			spans[attributeKey(bindings[prefix], local)] = span
+		}
+	}
+	return spans
 }
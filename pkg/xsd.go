@@ -3,16 +3,22 @@ package pkg
 import (
 	"encoding/xml"
 	"regexp"
+	"strings"
 )
 
 // XSDSchema ore types for XML Schema representation
 type XSDSchema struct {
-	XMLName            xml.Name         `xml:"schema"`
-	TargetNS           string           `xml:"targetNamespace,attr"`
-	ElementFormDefault string           `xml:"elementFormDefault,attr"`
-	Elements           []XSDElement     `xml:"element"`
-	ComplexTypes       []XSDComplexType `xml:"complexType"`
-	SimpleTypes        []XSDSimpleType  `xml:"simpleType"`
+	XMLName              xml.Name         `xml:"schema"`
+	TargetNS             string           `xml:"targetNamespace,attr"`
+	ElementFormDefault   string           `xml:"elementFormDefault,attr"`
+	AttributeFormDefault string           `xml:"attributeFormDefault,attr"`
+	Elements             []XSDElement     `xml:"element"`
+	Attributes           []XSDAttribute   `xml:"attribute"`
+	ComplexTypes         []XSDComplexType `xml:"complexType"`
+	SimpleTypes          []XSDSimpleType  `xml:"simpleType"`
+	Notations            []XSDNotation    `xml:"notation"`
+	Imports              []XSDImport      `xml:"import"`
+	Attrs                []xml.Attr       `xml:",any,attr"`
 }
 
 type XSDElement struct {
@@ -20,10 +26,32 @@ type XSDElement struct {
 	Namespace   string          `xml:"namespace,attr"`
 	Type        string          `xml:"type,attr"`
 	Ref         string          `xml:"ref,attr"`
+	Form        string          `xml:"form,attr"`
 	MinOccurs   string          `xml:"minOccurs,attr"`
 	MaxOccurs   string          `xml:"maxOccurs,attr"`
+	Default     string          `xml:"default,attr"`
+	Fixed       string          `xml:"fixed,attr"`
 	ComplexType *XSDComplexType `xml:"complexType"`
 	SimpleType  *XSDSimpleType  `xml:"simpleType"`
+
+	// targetNamespace is the namespace of the element's expanded name. It is
+	// set when the schema is compiled, from the scope and form of the
+	// declaration.
+	targetNamespace string
+}
+
+// XSDImport is an xs:import of the components of another namespace.
+type XSDImport struct {
+	Namespace      string `xml:"namespace,attr"`
+	SchemaLocation string `xml:"schemaLocation,attr"`
+}
+
+// XSDNotation is a notation declaration. Values of NOTATION types must name
+// a declared notation.
+type XSDNotation struct {
+	Name   string `xml:"name,attr"`
+	Public string `xml:"public,attr"`
+	System string `xml:"system,attr"`
 }
 
 type XSDElementRef struct {
@@ -59,11 +87,18 @@ type XSDChoice struct {
 
 type XSDAttribute struct {
 	Name       string         `xml:"name,attr"`
+	Ref        string         `xml:"ref,attr"`
+	Form       string         `xml:"form,attr"`
 	Type       string         `xml:"type,attr"`
 	Use        string         `xml:"use,attr"`
 	Default    string         `xml:"default,attr"`
 	Fixed      string         `xml:"fixed,attr"`
 	SimpleType *XSDSimpleType `xml:"simpleType"`
+
+	// schema is the validator of the schema document that declares a global
+	// attribute, which resolves the type of the declaration. It is nil for
+	// local declarations.
+	schema *Validator
 }
 
 type XSDRestriction struct {
@@ -77,22 +112,30 @@ type XSDRestriction struct {
 	MaxInclusive   XSDValue   `xml:"maxInclusive"`
 	MinExclusive   XSDValue   `xml:"minExclusive"`
 	MaxExclusive   XSDValue   `xml:"maxExclusive"`
-	WhiteSpace     string     `xml:"whiteSpace,attr"`
-	TotalDigits    string     `xml:"totalDigits,attr"`
-	FractionDigits string     `xml:"fractionDigits,attr"`
+	WhiteSpace     XSDValue   `xml:"whiteSpace"`
+	TotalDigits    XSDValue   `xml:"totalDigits"`
+	FractionDigits XSDValue   `xml:"fractionDigits"`
 }
 
 type XSDValue struct {
 	Value string `xml:"value,attr"`
+	Fixed string `xml:"fixed,attr"`
 }
 
 type XSDUnion struct {
-	MemberTypes []string        `xml:"memberTypes,attr"`
+	MemberTypes string          `xml:"memberTypes,attr"`
 	SimpleTypes []XSDSimpleType `xml:"simpleType"`
 }
 
+// MemberTypeNames returns the member types listed in the whitespace-separated
+// memberTypes attribute.
+func (u *XSDUnion) MemberTypeNames() []string {
+	return strings.Fields(u.MemberTypes)
+}
+
 type XSDList struct {
-	ItemType string `xml:"itemType,attr"`
+	ItemType   string         `xml:"itemType,attr"`
+	SimpleType *XSDSimpleType `xml:"simpleType"`
 }
 
 // PatternCache for performance
@@ -105,3 +148,21 @@ func NewPatternCache() *PatternCache {
 		patterns: make(map[string]*regexp.Regexp),
 	}
 }
+
+// Compile translates an XSD pattern into an anchored Go regular expression
+// and caches the result.
+func (c *PatternCache) Compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := c.patterns[pattern]; ok {
+		return re, nil
+	}
+	translated, err := translatePattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(translated)
+	if err != nil {
+		return nil, err
+	}
+	c.patterns[pattern] = re
+	return re, nil
+}